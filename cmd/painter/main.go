@@ -1,14 +1,87 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/roman-mazur/architecture-lab-3/painter"
 	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
 	"github.com/roman-mazur/architecture-lab-3/ui"
+	"golang.org/x/exp/shiny/screen"
 )
 
 func main() {
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "render" {
+		os.Exit(render(args[1:]))
+	}
+	os.Exit(run(args))
+}
+
+// run запускає сервер і вікно, або лише сервер у режимі -headless, та повертає код завершення програми.
+// Підкоманда replay додатково відтворює записаний журнал сесії.
+func run(args []string) int {
+	fs := flag.NewFlagSet("painter", flag.ContinueOnError)
+	replay := len(args) > 0 && args[0] == "replay"
+	var speed *float64
+	if replay {
+		args = args[1:]
+		fs = flag.NewFlagSet("painter replay", flag.ContinueOnError)
+		speed = fs.Float64("speed", 1, "replay speed multiplier, 0 to post scripts without delays")
+		fs.Usage = func() {
+			fmt.Fprintln(fs.Output(), "Usage: painter replay [flags] <session log>")
+			fs.PrintDefaults()
+		}
+	}
+
+	addr := fs.String("addr", envOr(addrEnv, defaultAddr), "HTTP listen address, host:port or unix:/path/to.sock; also $"+addrEnv)
+	headless := fs.Bool("headless", false, "serve commands and frames over HTTP without opening a window")
+	canvas := fs.String("canvas", "400x400", "canvas size in pixels, WIDTHxHEIGHT")
+	scale := fs.String("scale", "fit", "how the canvas fits the window: stretch, fit, fill or integer")
+	fps := fs.Int("fps", 60, "maximum frames per second rendered, 0 for no limit")
+	queue := fs.Int("queue", 1000, "maximum number of pending operations, 0 for no limit")
+	overflow := fs.String("overflow", "reject", "what to do when the queue is full: block, drop-oldest, drop-newest or reject")
+	record := fs.String("record", "", "append every accepted script to this session log, see painter replay")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	var entries []lang.LogEntry
+	if replay {
+		// Прапорці можна задати і після імені файлу.
+		file := fs.Arg(0)
+		if err := fs.Parse(fs.Args()[min(1, fs.NArg()):]); err != nil {
+			return 2
+		}
+		if file == "" || fs.NArg() > 0 {
+			fs.Usage()
+			return 2
+		}
+		var err error
+		if entries, err = readLog(file); err != nil {
+			log.Printf("Cannot read session log: %s", err)
+			return 1
+		}
+	}
+
+	size, err := parseSize(*canvas)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+
 	var (
 		pv ui.Visualizer // Візуалізатор створює вікно та малює у ньому.
 
@@ -17,17 +90,152 @@ func main() {
 		parser lang.Parser  // Парсер команд.
 	)
 
+	session := lang.NewSession() // Стан сцени, спільний для всіх запитів.
+	if *record != "" {
+		f, err := os.OpenFile(*record, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Printf("Cannot open session log: %s", err)
+			return 1
+		}
+		defer f.Close()
+		session.Log = lang.NewScriptLog(f)
+	}
+
+	// Останній кадр для /snapshot.png зберігається одразу, а вікно та інші отримувачі отримують копії кадрів
+	// у власних горутинах, щоб повільне вікно не затримувало цикл подій.
+	var receivers painter.MultiReceiver
+	defer receivers.Close()
+	recorder := &painter.FrameRecorder{Next: &receivers}
+	if !*headless {
+		receivers.AddReceiver(&pv)
+	}
+
 	//pv.Debug = true
 	pv.Title = "Simple painter"
 
-	pv.OnScreenReady = opLoop.Start
-	opLoop.Receiver = &pv
+	mode, err := ui.ParseScaleMode(*scale)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	pv.Scale = mode
+
+	policy, err := painter.ParseOverflowPolicy(*overflow)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+
+	// Кадри формуються у пам'яті, щоб їх можна було віддати через HTTP, а вікно лише показує їх.
+	pv.OnScreenReady = func(screen.Screen) { opLoop.Start(offscreen.NewScreen()) }
+	opLoop.Receiver = recorder
+	opLoop.Size = size
+	opLoop.MaxFPS = *fps
+	opLoop.QueueSize = *queue
+	opLoop.Overflow = policy
+	parser.Size = size
+
+	// Порт відкривається до створення вікна, щоб зайнята адреса одразу завершувала програму з помилкою.
+	ln, err := listen(*addr)
+	if err != nil {
+		log.Printf("Cannot listen on %s: %s", *addr, err)
+		return 1
+	}
+	commands := lang.HttpHandler(&opLoop, &parser, session)
+	server := newServer(&opLoop, commands, session, recorder)
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
 	go func() {
-		http.Handle("/", lang.HttpHandler(&opLoop, &parser))
-		_ = http.ListenAndServe("localhost:17000", nil)
+		if err := server.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
 	}()
+	// Сигнал або збій сервера завершують роботу: без вікна одразу, а з вікном — закриваючи його,
+	// після чого pv.Main повертається.
+	waitExit := func() {
+		select {
+		case <-ctx.Done():
+		case err := <-serveErr:
+			serveErr <- err
+		}
+	}
+	if replay {
+		// Операції чекають у черзі, доки вікно не запустить цикл подій.
+		go func() {
+			if err := commands.Replay(ctx, entries, *speed); err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Replay failed: %s", err)
+				}
+				return
+			}
+			log.Printf("Replayed %d scripts", len(entries))
+		}()
+	}
+	if *headless {
+		log.Printf("Serving without a window on %s", *addr)
+		opLoop.Start(offscreen.NewScreen())
+		waitExit()
+	} else {
+		go func() {
+			waitExit()
+			pv.Close()
+		}()
+		pv.Main()
+	}
+
+	code := 0
+	select {
+	case err := <-serveErr:
+		log.Printf("HTTP server failed: %s", err)
+		code = 1
+	default:
+	}
+
+	// Спершу сервер перестає приймати команди, потім цикл подій виконує ті, що вже в черзі.
+	// Кожен етап має власний тайм-аут, тож повільне завершення запитів не забирає час у черги.
+	if err := shutdown(server.Shutdown); err != nil {
+		log.Printf("HTTP server shutdown: %s", err)
+	}
+	if err := shutdown(opLoop.StopAndWait); err != nil {
+		log.Printf("Painter loop shutdown: %s", err)
+		code = 1
+	}
+	return code
+}
+
+// shutdown викликає stop з контекстом, що спливає через shutdownTimeout.
+func shutdown(stop func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return stop(ctx)
+}
+
+// readLog читає журнал сесії з файлу path.
+func readLog(path string) ([]lang.LogEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return lang.ReadLog(f)
+}
+
+// parseSize читає розмір полотна у форматі WIDTHxHEIGHT.
+func parseSize(s string) (image.Point, error) {
+	var size image.Point
+	if _, err := fmt.Sscanf(s, "%dx%d", &size.X, &size.Y); err != nil || size.X <= 0 || size.Y <= 0 {
+		return image.Point{}, fmt.Errorf("invalid canvas size %q, expected WIDTHxHEIGHT", s)
+	}
+	return size, nil
+}
 
-	pv.Main()
-	opLoop.StopAndWait()
+// envOr повертає значення змінної середовища name або def, якщо її не задано.
+func envOr(name, def string) string {
+	if v, ok := os.LookupEnv(name); ok && v != "" {
+		return v
+	}
+	return def
 }
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"golang.org/x/exp/shiny/screen"
+)
+
+// render виконує скрипт без вікна і записує всі кадри, зокрема кадри анімацій, у GIF або послідовність PNG.
+func render(args []string) int {
+	fs := flag.NewFlagSet("painter render", flag.ContinueOnError)
+	script := fs.String("script", "", "script to render, text or JSON by the .json extension; - reads text from stdin")
+	out := fs.String("out", "", "output: file.gif, a PNG name pattern such as frame%03d.png, or a directory for numbered PNGs")
+	fps := fs.Int("fps", 20, "frames per second of animations and of the output")
+	canvas := fs.String("canvas", "400x400", "canvas size in pixels, WIDTHxHEIGHT")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: painter render -script anim.txt -out anim.gif [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if *script == "" || *out == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	if *fps <= 0 {
+		log.Printf("Invalid fps %d, expected a positive number", *fps)
+		return 2
+	}
+	size, err := parseSize(*canvas)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+
+	fw, err := newFrameWriter(*out, *fps)
+	if err != nil {
+		log.Printf("Cannot create output: %s", err)
+		return 1
+	}
+
+	parser := lang.Parser{Size: size}
+	var decoder lang.Decoder = &parser
+	if strings.EqualFold(filepath.Ext(*script), ".json") {
+		decoder = &lang.JSONParser{Parser: &parser}
+	}
+	in, err := openScript(*script)
+	if err != nil {
+		log.Printf("Cannot read script: %s", err)
+		return 1
+	}
+	ops, err := lang.NewSession().Parse(decoder, in)
+	in.Close()
+	if err != nil {
+		log.Printf("Bad script: %s", err)
+		return 1
+	}
+
+	// MaxFPS не задається, щоб Loop не об'єднував кадри: у вихідний файл потрапляє кожен кадр.
+	capture := &frameCapture{w: fw}
+	loop := painter.Loop{Receiver: capture, Size: size, AnimationFPS: *fps}
+	loop.Start(offscreen.NewScreen())
+
+	// Анімації відтворюються в реальному часі. Перерваний рендер записує кадри, отримані до сигналу.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := postFrames(ctx, &loop, ops); err != nil && ctx.Err() == nil {
+		log.Printf("Operations not posted: %s", err)
+		return 1
+	}
+	if err := loop.WaitAnimations(ctx); err != nil {
+		log.Printf("Interrupted, writing the frames rendered so far")
+		if err := loop.Post(painter.StopAnimationOp{ID: "*"}); err != nil {
+			log.Printf("Operations not posted: %s", err)
+			return 1
+		}
+	}
+	// StopAndWait чекає, доки Loop виконає решту черги, тож останній кадр анімації теж потрапляє у вихідний файл.
+	_ = loop.StopAndWait(context.Background())
+
+	if capture.err == nil && capture.frames == 0 {
+		capture.err = errors.New("the script rendered no frames, did you forget update?")
+	}
+	if err := errors.Join(capture.err, fw.Close()); err != nil {
+		log.Printf("Cannot write %s: %s", *out, err)
+		return 1
+	}
+	log.Printf("Rendered %d frames to %s", capture.frames, *out)
+	return 0
+}
+
+// postFrames передає операції у loop частинами, що закінчуються update, і чекає на показ кожного кадру,
+// тож кожен update скрипту стає окремим кадром.
+func postFrames(ctx context.Context, loop *painter.Loop, ops []painter.Operation) error {
+	for len(ops) > 0 {
+		n := len(ops)
+		if i := slices.Index(ops, painter.Operation(painter.UpdateOp)); i >= 0 {
+			n = i + 1
+		}
+		if err := loop.PostAndWait(ctx, painter.OperationList(ops[:n])); err != nil {
+			return err
+		}
+		ops = ops[n:]
+	}
+	return nil
+}
+
+func openScript(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+// frameCapture передає кожен кадр Loop у frameWriter. Update викликається лише з горутини циклу подій,
+// а поля читаються після його зупинки.
+type frameCapture struct {
+	w      frameWriter
+	frames int
+	err    error // перша помилка запису, після якої кадри ігноруються
+}
+
+func (c *frameCapture) Update(t screen.Texture) {
+	img := offscreen.Snapshot(t)
+	if img == nil || c.err != nil {
+		return
+	}
+	c.err = c.w.WriteFrame(img, time.Now())
+	c.frames++
+}
+
+// frameWriter записує кадри по одному. at — час, коли кадр показано. Close завершує вихідний файл.
+type frameWriter interface {
+	WriteFrame(img *image.RGBA, at time.Time) error
+	Close() error
+}
+
+// newFrameWriter обирає формат за out: GIF для файлу .gif, PNG за шаблоном з % або нумеровані PNG у каталозі.
+func newFrameWriter(out string, fps int) (frameWriter, error) {
+	switch {
+	case strings.EqualFold(filepath.Ext(out), ".gif"):
+		return &gifWriter{path: out, delay: max(1, (100+fps/2)/fps)}, nil
+	case strings.Contains(out, "%"):
+		return &pngWriter{pattern: out}, nil
+	default:
+		if err := os.MkdirAll(out, 0o755); err != nil {
+			return nil, err
+		}
+		return &pngWriter{pattern: filepath.Join(out, "frame%04d.png")}, nil
+	}
+}
+
+// gifWriter накопичує кадри з палітрою і записує анімований GIF у Close, якщо є хоча б один кадр.
+// Кадр триває до показу наступного, але не менше delay, тож анімація з пропущеними кадрами зберігає
+// свою тривалість, а кадри, показані майже одночасно, тривають по одному кадру fps.
+type gifWriter struct {
+	path  string
+	delay int // найменша затримка кадру в сотих частках секунди
+	anim  gif.GIF
+	last  time.Time // час показу попереднього кадру
+}
+
+func (w *gifWriter) WriteFrame(img *image.RGBA, at time.Time) error {
+	if n := len(w.anim.Delay); n > 0 {
+		shown := int((at.Sub(w.last) + 5*time.Millisecond) / (10 * time.Millisecond))
+		w.anim.Delay[n-1] = max(w.delay, shown)
+	}
+	w.anim.Image = append(w.anim.Image, quantize(img))
+	w.anim.Delay = append(w.anim.Delay, w.delay)
+	w.last = at
+	return nil
+}
+
+func (w *gifWriter) Close() error {
+	if len(w.anim.Image) == 0 {
+		return nil
+	}
+	f, err := os.Create(w.path)
+	if err != nil {
+		return err
+	}
+	return errors.Join(gif.EncodeAll(f, &w.anim), f.Close())
+}
+
+// quantize переводить кадр у палітру GIF. Кадри, що мають не більше 256 кольорів, зберігаються точно,
+// решта зводиться до палітри Plan 9 з розсіюванням Флойда—Стейнберга.
+func quantize(img *image.RGBA) *image.Paletted {
+	if p := exactPalette(img); p != nil {
+		dst := image.NewPaletted(img.Rect, p)
+		draw.Draw(dst, dst.Rect, img, img.Rect.Min, draw.Src)
+		return dst
+	}
+	dst := image.NewPaletted(img.Rect, palette.Plan9)
+	draw.FloydSteinberg.Draw(dst, dst.Rect, img, img.Rect.Min)
+	return dst
+}
+
+// exactPalette повертає всі кольори кадру або nil, якщо їх більше, ніж вміщує палітра GIF.
+func exactPalette(img *image.RGBA) color.Palette {
+	seen := make(map[color.RGBA]bool)
+	var p color.Palette
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			if seen[c] {
+				continue
+			}
+			if len(p) == 256 {
+				return nil
+			}
+			seen[c] = true
+			p = append(p, c)
+		}
+	}
+	return p
+}
+
+// pngWriter записує кожен кадр в окремий PNG файл, ім'я якого отримується з шаблону та номера кадру.
+type pngWriter struct {
+	pattern string
+	n       int
+}
+
+func (w *pngWriter) WriteFrame(img *image.RGBA, _ time.Time) error {
+	w.n++
+	f, err := os.Create(fmt.Sprintf(w.pattern, w.n))
+	if err != nil {
+		return err
+	}
+	return errors.Join(png.Encode(f, img), f.Close())
+}
+
+func (w *pngWriter) Close() error { return nil }
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+)
+
+// testFrame створює кадр 4×4, у якому кожен піксель має колір c(x, y).
+func testFrame(c func(x, y int) color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.SetRGBA(x, y, c(x, y))
+		}
+	}
+	return img
+}
+
+func TestQuantize_Exact(t *testing.T) {
+	img := testFrame(func(x, y int) color.RGBA { return color.RGBA{R: uint8(x * 60), G: uint8(y * 60), A: 255} })
+
+	if p := exactPalette(img); len(p) != 16 {
+		t.Fatalf("expected 16 colors in the palette, got %d", len(p))
+	}
+	dst := quantize(img)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if got, want := color.RGBAModel.Convert(dst.At(x, y)), img.RGBAAt(x, y); got != want {
+				t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestQuantize_ManyColors(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for i := range 32 * 32 {
+		img.SetRGBA(i%32, i/32, color.RGBA{R: uint8(i), G: uint8(i >> 8), A: 255})
+	}
+
+	if p := exactPalette(img); p != nil {
+		t.Fatalf("expected no exact palette for %d colors, got %d", 32*32, len(p))
+	}
+	if dst := quantize(img); len(dst.Palette) != len(palette.Plan9) {
+		t.Errorf("expected the Plan 9 palette, got %d colors", len(dst.Palette))
+	}
+}
+
+func TestGifWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.gif")
+	w := &gifWriter{path: path, delay: 5}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("no file must be written without frames, got %v", err)
+	}
+
+	frame := testFrame(func(x, y int) color.RGBA { return color.RGBA{B: 255, A: 255} })
+	start := time.Now()
+	for _, at := range []time.Duration{0, 10 * time.Millisecond, 210 * time.Millisecond} {
+		if err := w.WriteFrame(frame, start.Add(at)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	anim, err := gif.DecodeAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Кадр, замінений майже одразу, триває delay, а решта — до показу наступного кадру.
+	want := []int{5, 20, 5}
+	if len(anim.Delay) != len(want) {
+		t.Fatalf("expected %d frames, got %d", len(want), len(anim.Delay))
+	}
+	for i, d := range want {
+		if anim.Delay[i] != d {
+			t.Errorf("frame %d delay %d, want %d", i, anim.Delay[i], d)
+		}
+	}
+}
+
+func TestPngWriter(t *testing.T) {
+	dir := t.TempDir()
+	w := &pngWriter{pattern: filepath.Join(dir, "f%02d.png")}
+	colors := []color.RGBA{{R: 255, A: 255}, {G: 255, A: 255}}
+	for _, c := range colors {
+		if err := w.WriteFrame(testFrame(func(x, y int) color.RGBA { return c }), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, c := range colors {
+		f, err := os.Open(filepath.Join(dir, []string{"f01.png", "f02.png"}[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := color.RGBAModel.Convert(img.At(1, 1)); got != c {
+			t.Errorf("frame %d has color %v, want %v", i+1, got, c)
+		}
+	}
+}
+
+// countWriter рахує кадри і запам'ятовує колір лівого верхнього пікселя кожного.
+type countWriter struct{ colors []color.RGBA }
+
+func (w *countWriter) WriteFrame(img *image.RGBA, _ time.Time) error {
+	w.colors = append(w.colors, img.RGBAAt(0, 0))
+	return nil
+}
+
+func (w *countWriter) Close() error { return nil }
+
+func TestPostFrames(t *testing.T) {
+	var w countWriter
+	loop := painter.Loop{Receiver: &frameCapture{w: &w}, Size: image.Pt(4, 4)}
+	loop.Start(offscreen.NewScreen())
+
+	ops := []painter.Operation{
+		painter.BackgroundOp(color.Black), painter.UpdateOp,
+		painter.BackgroundOp(color.White), painter.UpdateOp,
+		painter.BackgroundOp(color.Black), painter.UpdateOp,
+	}
+	if err := postFrames(context.Background(), &loop, ops); err != nil {
+		t.Fatal(err)
+	}
+	if err := loop.StopAndWait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(w.colors) != 3 || w.colors[1] != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("expected each update to be a separate frame, got %v", w.colors)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/frames"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+// Адреса HTTP сервера, якщо її не задано ні прапорцем -addr, ні змінною середовища addrEnv.
+const (
+	defaultAddr = "localhost:17000"
+	addrEnv     = "PAINTER_ADDR"
+)
+
+// Час, за який сервер, а потім окремо цикл подій мають завершити роботу після закриття вікна або сигналу.
+const shutdownTimeout = 5 * time.Second
+
+// listen відкриває TCP порт або, для адрес виду unix:/path/to.sock, Unix сокет.
+func listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// newServer створює HTTP сервер з обробниками команд і кадрів.
+func newServer(loop *painter.Loop, commands *lang.Handler, session *lang.Session, recorder *painter.FrameRecorder) *http.Server {
+	// Потоки подій тривають, доки клієнт не від'єднається, тож Shutdown не дочекався б їх.
+	// Вони завершуються, щойно сервер починає зупинку.
+	closing, cancel := context.WithCancel(context.Background())
+	stream := func(h http.Handler) http.Handler { return untilDone(closing, h) }
+
+	mux := http.NewServeMux()
+	mux.Handle("/", commands)
+	mux.Handle("/snapshot.png", frames.SnapshotHandler(recorder))
+	mux.Handle("/undo", lang.UndoHandler(loop, session))
+	mux.Handle("/redo", lang.RedoHandler(loop, session))
+	mux.Handle("/stats", frames.StatsHandler(loop))
+	mux.Handle("/stream/frames", stream(frames.FramesHandler(recorder)))
+	mux.Handle("/stream/events", stream(lang.EventsHandler(session)))
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+	server.RegisterOnShutdown(cancel)
+	return server
+}
+
+// untilDone скасовує контекст запиту до h, щойно завершується ctx.
+func untilDone(ctx context.Context, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		reqCtx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		defer context.AfterFunc(ctx, cancel)()
+		h.ServeHTTP(rw, r.WithContext(reqCtx))
+	})
+}
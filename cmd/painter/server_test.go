@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func TestServerShutdownClosesStreams(t *testing.T) {
+	var loop painter.Loop
+	session := lang.NewSession()
+	server := newServer(&loop, lang.HttpHandler(&loop, &lang.Parser{}, session), session, &painter.FrameRecorder{})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+
+	for _, path := range []string{"/stream/events", "/stream/frames"} {
+		resp, err := http.Get("http://" + ln.Addr().String() + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: unexpected status %s", path, resp.Status)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Errorf("open streams must not block shutdown: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package painter
+
+import (
+	"context"
+	"math"
+	"path"
+	"runtime/debug"
+	"time"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// DefaultAnimationFPS — частота кадрів анімацій, якщо Loop.AnimationFPS не задано.
+const DefaultAnimationFPS = 30
+
+// Easing перетворює частку часу анімації від 0 до 1 на частку пройденого шляху.
+type Easing func(t float64) float64
+
+func Linear(t float64) float64  { return t }
+func EaseIn(t float64) float64  { return t * t * t }
+func EaseOut(t float64) float64 { return 1 - math.Pow(1-t, 3) }
+func EaseInOut(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	return 1 - math.Pow(-2*t+2, 3)/2
+}
+
+// Easings зіставляє назви функцій згладжування зі значеннями.
+var Easings = map[string]Easing{
+	"linear":      Linear,
+	"ease-in":     EaseIn,
+	"ease-out":    EaseOut,
+	"ease-in-out": EaseInOut,
+}
+
+// Animation описує анімацію, яку Loop виконує з частотою AnimationFPS.
+type Animation struct {
+	// ID ідентифікує анімацію для зупинки. Нова анімація з тим самим ID замінює попередню.
+	ID       string
+	Duration time.Duration
+	// Easing задає швидкість руху, якщо nil — рух рівномірний.
+	Easing Easing
+	// Step повертає операцію, що формує кадр для частки шляху progress. Останній кадр завжди має progress 1.
+	// Якщо Step повертає nil, анімація завершується.
+	Step func(progress float64) Operation
+}
+
+// AnimateOp запускає анімацію у Loop.
+type AnimateOp struct {
+	Animation
+}
+
+// Do нічого не робить з текстурою: анімацію запускає сам Loop.
+func (op AnimateOp) Do(t screen.Texture) bool { return false }
+
+// StopAnimationOp зупиняє анімації, ідентифікатори яких відповідають шаблону ID у синтаксисі path.Match.
+// Фігури лишаються там, куди встигли переміститися.
+type StopAnimationOp struct {
+	ID string
+}
+
+// Do нічого не робить з текстурою: анімацію зупиняє сам Loop.
+func (op StopAnimationOp) Do(t screen.Texture) bool { return false }
+
+// animation — запущена анімація та канал для її зупинки.
+type animation struct {
+	Animation
+	stop chan struct{}
+}
+
+// animationDone повідомляє Loop, що анімація завершилась сама або через паніку в Step.
+type animationDone struct {
+	a   *animation
+	err error
+}
+
+func (animationDone) Do(t screen.Texture) bool { return false }
+
+// startAnimation запускає горутину, яка з частотою кадрів передає кроки анімації у чергу Loop.
+// Викликається лише з горутини циклу подій.
+func (l *Loop) startAnimation(a Animation) {
+	if l.animations == nil {
+		l.animations = make(map[string]*animation)
+	}
+	if old, ok := l.animations[a.ID]; ok {
+		close(old.stop)
+	}
+	anim := &animation{Animation: a, stop: make(chan struct{})}
+	l.animations[a.ID] = anim
+
+	fps := l.AnimationFPS
+	if fps <= 0 {
+		fps = DefaultAnimationFPS
+	}
+	go l.runAnimation(anim, time.Second/time.Duration(fps))
+}
+
+func (l *Loop) runAnimation(a *animation, interval time.Duration) {
+	ease := a.Easing
+	if ease == nil {
+		ease = Linear
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		select {
+		case <-a.stop:
+			return
+		case now := <-ticker.C:
+			progress := 1.0
+			if a.Duration > 0 {
+				progress = min(float64(now.Sub(start))/float64(a.Duration), 1)
+			}
+
+			// Кадр анімації може бути відкинутий переповненою чергою, наступний кадр однаково перемальовує сцену.
+			op, err := a.step(ease(progress))
+			if err != nil {
+				_ = l.mq.push(animationDone{a, err}, true)
+				return
+			}
+			_ = l.Post(op)
+			if op == nil || progress >= 1 {
+				_ = l.mq.push(animationDone{a: a}, true)
+				return
+			}
+		}
+	}
+}
+
+// step викликає Step, перетворюючи паніку на PanicError, щоб вона не зупинила програму.
+func (a *animation) step(progress float64) (op Operation, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Op: AnimateOp{a.Animation}, Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return a.Step(progress), nil
+}
+
+// stopAnimations зупиняє анімації, що відповідають шаблону. Викликається лише з горутини циклу подій.
+func (l *Loop) stopAnimations(pattern string) {
+	for id, a := range l.animations {
+		if ok, _ := path.Match(pattern, id); ok {
+			close(a.stop)
+			delete(l.animations, id)
+		}
+	}
+	l.notifyIdle()
+}
+
+// WaitAnimations чекає, доки завершаться всі анімації, зокрема запущені операціями AnimateOp, які вже є в черзі.
+// Останній кадр анімації на цей момент може бути відкладений через MaxFPS. Після зупинки Loop анімацій немає,
+// тож метод одразу повертається. Якщо ctx завершується раніше, повертається його помилка.
+func (l *Loop) WaitAnimations(ctx context.Context) error {
+	idle := make(animationsIdle)
+	_ = l.mq.push(idle, true)
+	select {
+	case <-idle:
+		return nil
+	case <-l.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// animationsIdle закривається, коли в Loop не лишається запущених анімацій.
+type animationsIdle chan struct{}
+
+func (animationsIdle) Do(t screen.Texture) bool { return false }
+
+// notifyIdle повідомляє WaitAnimations, якщо анімацій не лишилось. Викликається лише з горутини циклу подій.
+func (l *Loop) notifyIdle() {
+	if len(l.animations) > 0 {
+		return
+	}
+	for _, idle := range l.idle {
+		close(idle)
+	}
+	l.idle = nil
+}
@@ -0,0 +1,137 @@
+package painter
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+func TestEasings(t *testing.T) {
+	for name, ease := range Easings {
+		if ease(0) != 0 || math.Abs(ease(1)-1) > 1e-9 {
+			t.Errorf("%s must map 0 to 0 and 1 to 1, got %v and %v", name, ease(0), ease(1))
+		}
+		for p := 0.1; p < 1; p += 0.1 {
+			if ease(p) < ease(p-0.1) {
+				t.Errorf("%s must not go backwards at %v", name, p)
+			}
+		}
+	}
+	if EaseInOut(0.5) != 0.5 {
+		t.Errorf("ease-in-out must be symmetric, got %v at 0.5", EaseInOut(0.5))
+	}
+}
+
+func TestLoop_Animate(t *testing.T) {
+	l := Loop{AnimationFPS: 100}
+	startLoop(t, &l, mockScreen{})
+
+	steps := make(chan float64, 100)
+	l.Post(AnimateOp{Animation{
+		ID:       "a",
+		Duration: 50 * time.Millisecond,
+		Step: func(p float64) Operation {
+			steps <- p
+			return OperationFunc(func(screen.Texture) {})
+		},
+	}})
+
+	var last float64
+	timeout := time.After(time.Second)
+	for last < 1 {
+		select {
+		case p := <-steps:
+			if p < last {
+				t.Fatalf("progress went backwards: %v after %v", p, last)
+			}
+			last = p
+		case <-timeout:
+			t.Fatalf("animation did not finish, last progress %v", last)
+		}
+	}
+}
+
+func TestLoop_StopAnimation(t *testing.T) {
+	l := Loop{AnimationFPS: 100}
+	startLoop(t, &l, mockScreen{})
+
+	steps := make(chan float64, 1000)
+	l.Post(AnimateOp{Animation{
+		ID:       "a",
+		Duration: time.Hour,
+		Step: func(p float64) Operation {
+			steps <- p
+			return nil
+		},
+	}})
+	<-steps
+
+	// Step повернув nil, тож анімація вже завершилась; нова анімація зупиняється явно.
+	l.Post(AnimateOp{Animation{
+		ID:       "b",
+		Duration: time.Hour,
+		Step: func(p float64) Operation {
+			steps <- p
+			return OperationFunc(func(screen.Texture) {})
+		},
+	}})
+	<-steps
+	l.Post(StopAnimationOp{ID: "*"})
+
+	done := make(chan struct{})
+	l.Post(OperationFunc(func(screen.Texture) { close(done) }))
+	<-done
+	for len(steps) > 0 {
+		<-steps
+	}
+	// Крок, що вже виконувався під час зупинки, може завершитися, але нових бути не повинно.
+	time.Sleep(50 * time.Millisecond)
+	if n := len(steps); n > 1 {
+		t.Errorf("stopped animation made %d more steps", n)
+	}
+}
+
+func TestLoop_WaitAnimations(t *testing.T) {
+	l := Loop{AnimationFPS: 100}
+	startLoop(t, &l, mockScreen{})
+
+	if err := l.WaitAnimations(context.Background()); err != nil {
+		t.Fatalf("expected no animations to wait for, got %v", err)
+	}
+
+	var last float64
+	l.Post(AnimateOp{Animation{
+		ID:       "a",
+		Duration: 50 * time.Millisecond,
+		Step: func(p float64) Operation {
+			last = p
+			return OperationFunc(func(screen.Texture) {})
+		},
+	}})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.WaitAnimations(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if last != 1 {
+		t.Errorf("expected animation to finish before WaitAnimations returned, last progress %v", last)
+	}
+
+	l.Post(AnimateOp{Animation{
+		ID:       "b",
+		Duration: time.Hour,
+		Step:     func(float64) Operation { return OperationFunc(func(screen.Texture) {}) },
+	}})
+	short, cancelShort := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancelShort()
+	if err := l.WaitAnimations(short); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline while animation runs, got %v", err)
+	}
+	l.Post(StopAnimationOp{ID: "b"})
+	if err := l.WaitAnimations(ctx); err != nil {
+		t.Errorf("expected stopped animation to release waiters, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package painter
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// ErrOperation — операція, яка може повідомити про помилку. Loop викликає DoErr замість Do.
+// Помилка передається у Loop.OnError, а для PostFuture — у Result.Err.
+type ErrOperation interface {
+	Operation
+	DoErr(t screen.Texture) (ready bool, err error)
+}
+
+// PanicError — паніка під час виконання операції, яку перехопив Loop, щоб продовжити обробку черги.
+type PanicError struct {
+	Op    Operation
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("painter: operation %T panicked: %v", e.Op, e.Value)
+}
+
+// Unwrap повертає значення паніки, якщо воно є помилкою.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// exec виконує звичайну операцію, перетворюючи паніку на PanicError.
+func (l *Loop) exec(op Operation) (ready bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			ready = false
+			l.report(op, &PanicError{Op: op, Value: v, Stack: debug.Stack()})
+		}
+	}()
+
+	if eop, ok := op.(ErrOperation); ok {
+		ready, err := eop.DoErr(l.next)
+		if err != nil {
+			l.report(op, err)
+		}
+		return ready
+	}
+	return op.Do(l.next)
+}
+
+// report передає помилку операції у OnError або, якщо його не задано, у журнал.
+func (l *Loop) report(op Operation, err error) {
+	l.opErr = err
+	l.stats.errors.Add(1)
+	if l.OnError != nil {
+		l.OnError(op, err)
+		return
+	}
+	log.Printf("%s", err)
+}
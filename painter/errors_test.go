@@ -0,0 +1,64 @@
+package painter
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"golang.org/x/exp/shiny/screen"
+)
+
+// failingOp повертає помилку замість оновлення текстури.
+type failingOp struct{ err error }
+
+func (op failingOp) Do(t screen.Texture) bool { return false }
+
+func (op failingOp) DoErr(t screen.Texture) (bool, error) { return false, op.err }
+
+func TestLoop_RecoverPanic(t *testing.T) {
+	var errs []error
+	l := Loop{OnError: func(op Operation, err error) { errs = append(errs, err) }}
+	tr := startLoop(t, &l, offscreen.NewScreen())
+
+	var nilFunc OperationFunc
+	l.Post(nilFunc)
+	l.Post(OperationFunc(func(screen.Texture) { panic("boom") }))
+	l.Post(OperationList{BackgroundOp(color.White), UpdateOp})
+	l.StopAndWait(context.Background())
+
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 reported errors, got %v", errs)
+	}
+	var pe *PanicError
+	if !errors.As(errs[1], &pe) || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Errorf("expected PanicError with value and stack, got %#v", errs[1])
+	}
+	if tr.lastTexture == nil {
+		t.Error("loop must keep processing operations after a panic")
+	}
+	if n := l.Stats().Errors; n != 2 {
+		t.Errorf("expected 2 errors in stats, got %d", n)
+	}
+}
+
+func TestLoop_ErrOperation(t *testing.T) {
+	var reported error
+	l := Loop{OnError: func(op Operation, err error) { reported = err }}
+	startLoop(t, &l, offscreen.NewScreen())
+
+	errFailed := errors.New("failed")
+	res := <-l.PostFuture(OperationList{failingOp{errFailed}, UpdateOp})
+	if res.Err != errFailed {
+		t.Errorf("future must get the operation error, got %v", res.Err)
+	}
+	if res := <-l.PostFuture(UpdateOp); res.Err != nil {
+		t.Errorf("error must not leak into the next future, got %v", res.Err)
+	}
+	l.StopAndWait(context.Background())
+
+	if reported != errFailed {
+		t.Errorf("OnError must get the operation error, got %v", reported)
+	}
+}
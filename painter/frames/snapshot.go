@@ -0,0 +1,92 @@
+package frames
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"golang.org/x/image/draw"
+)
+
+// Найбільший допустимий множник масштабу знімка.
+const maxSnapshotScale = 8
+
+// SnapshotHandler конструює обробник, який віддає останній кадр з FrameRecorder як PNG або JPEG.
+// Параметр scale змінює розмір зображення, а format=jpeg обирає формат JPEG.
+func SnapshotHandler(rec *painter.FrameRecorder) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			rw.Header().Set("Allow", "GET, HEAD")
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		scale, format, err := imageParams(r)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		frame := rec.Frame()
+		if frame == nil {
+			http.Error(rw, "no frame rendered yet", http.StatusNotFound)
+			return
+		}
+
+		rw.Header().Set("Cache-Control", "no-store")
+		rw.Header().Set("Content-Type", contentType(format))
+		_ = encodeImage(rw, frame, scale, format)
+	})
+}
+
+// imageParams читає параметри scale та format запиту кадру.
+func imageParams(r *http.Request) (scale float64, format string, err error) {
+	q := r.URL.Query()
+	scale = 1
+	if v := q.Get("scale"); v != "" {
+		s, err := strconv.ParseFloat(v, 64)
+		if err != nil || s <= 0 || s > maxSnapshotScale {
+			return 0, "", fmt.Errorf("invalid scale: %s", v)
+		}
+		scale = s
+	}
+
+	switch format = q.Get("format"); format {
+	case "", "png":
+		format = "png"
+	case "jpeg", "jpg":
+		format = "jpeg"
+	default:
+		return 0, "", fmt.Errorf("unsupported format: %s", format)
+	}
+	return scale, format, nil
+}
+
+func contentType(format string) string {
+	return "image/" + format
+}
+
+// encodeImage записує кадр, змінений у scale разів, у форматі png або jpeg.
+func encodeImage(w io.Writer, frame *image.RGBA, scale float64, format string) error {
+	var img image.Image = frame
+	if scale != 1 {
+		img = scaleImage(frame, scale)
+	}
+	if format == "jpeg" {
+		return jpeg.Encode(w, img, nil)
+	}
+	return png.Encode(w, img)
+}
+
+func scaleImage(src *image.RGBA, scale float64) *image.RGBA {
+	size := src.Bounds().Size()
+	w, h := max(1, int(float64(size.X)*scale)), max(1, int(float64(size.Y)*scale))
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return dst
+}
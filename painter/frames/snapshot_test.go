@@ -0,0 +1,59 @@
+package frames_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/frames"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+)
+
+func TestSnapshotHandler(t *testing.T) {
+	rec := &painter.FrameRecorder{}
+	handler := frames.SnapshotHandler(rec)
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/snapshot.png", nil))
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("expected 404 before first frame, got %d", resp.Code)
+	}
+
+	tx, _ := offscreen.NewScreen().NewTexture(image.Pt(40, 20))
+	tx.Fill(tx.Bounds(), color.White, draw.Src)
+	rec.Update(tx)
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/snapshot.png?scale=0.5", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+	if ct := resp.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	img, err := png.Decode(resp.Body)
+	if err != nil {
+		t.Fatalf("response is not a PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size != image.Pt(20, 10) {
+		t.Errorf("expected scaled size 20x10, got %v", size)
+	}
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/snapshot.png?format=jpeg", nil))
+	if ct := resp.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/snapshot.png?scale=abc", nil))
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for bad scale, got %d", resp.Code)
+	}
+}
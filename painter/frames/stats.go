@@ -0,0 +1,23 @@
+// Package frames віддає через HTTP кадри, які painter.Loop передає у painter.FrameRecorder,
+// та лічильники кадрів Loop.
+package frames
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// StatsHandler конструює обробник GET запитів, який повертає лічильники кадрів loop у форматі JSON.
+func StatsHandler(loop *painter.Loop) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			rw.Header().Set("Allow", "GET, HEAD")
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(loop.Stats())
+	})
+}
@@ -0,0 +1,31 @@
+package frames_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/frames"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+)
+
+func TestStatsHandler(t *testing.T) {
+	loop := &painter.Loop{Receiver: &painter.FrameRecorder{}}
+	loop.Start(offscreen.NewScreen())
+	loop.Post(painter.OperationList{painter.UpdateOp, painter.UpdateOp})
+	loop.StopAndWait(context.Background())
+
+	resp := httptest.NewRecorder()
+	frames.StatsHandler(loop).ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	var stats painter.Stats
+	if err := json.Unmarshal(resp.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", resp.Body, err)
+	}
+	if stats != (painter.Stats{Frames: 1, Coalesced: 1}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,66 @@
+package frames
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"net/http"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/internal/sse"
+)
+
+// FramesHandler конструює обробник, який надсилає кадри з rec потоком Server-Sent Events. Кожна подія frame
+// містить data URL із зображенням, тож браузер може одразу показати його в <img>. Параметри scale та format
+// такі самі, як у SnapshotHandler. Клієнт, що не встигає, отримує лише найновіші кадри.
+func FramesHandler(rec *painter.FrameRecorder) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		scale, format, err := imageParams(r)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		frames, cancel := rec.Subscribe()
+		defer cancel()
+
+		stream, ok := sse.Start(rw, r)
+		if !ok {
+			return
+		}
+		defer stream.Stop()
+
+		var buf bytes.Buffer
+		sendFrame := func(frame *image.RGBA) error {
+			buf.Reset()
+			fmt.Fprintf(&buf, "data:%s;base64,", contentType(format))
+			enc := base64.NewEncoder(base64.StdEncoding, &buf)
+			if err := encodeImage(enc, frame, scale, format); err != nil {
+				return err
+			}
+			if err := enc.Close(); err != nil {
+				return err
+			}
+			return stream.Send("frame", buf.Bytes())
+		}
+
+		// Новий клієнт одразу бачить поточний кадр, не чекаючи на наступне оновлення.
+		if frame := rec.Frame(); frame != nil && sendFrame(frame) != nil {
+			return
+		}
+		for {
+			select {
+			case frame := <-frames:
+				if sendFrame(frame) != nil {
+					return
+				}
+			case <-stream.KeepAlive:
+				if stream.Comment() != nil {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+}
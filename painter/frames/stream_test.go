@@ -0,0 +1,48 @@
+package frames_test
+
+import (
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/frames"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+)
+
+func TestFramesHandler(t *testing.T) {
+	rec := &painter.FrameRecorder{}
+	tx, _ := offscreen.NewScreen().NewTexture(image.Pt(40, 20))
+	tx.Fill(tx.Bounds(), color.White, draw.Src)
+	rec.Update(tx)
+
+	// Потік триває, доки клієнт не від'єднається, тож запит скасовується, щойно надіслано поточний кадр.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	resp := httptest.NewRecorder()
+	frames.FramesHandler(rec).ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/stream/frames", nil).WithContext(ctx))
+
+	if ct := resp.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	event, _, _ := strings.Cut(resp.Body.String(), "\n\n")
+	encoded, ok := strings.CutPrefix(event, "event: frame\ndata: data:image/png;base64,")
+	if !ok {
+		t.Fatalf("expected frame event with PNG data URL, got %.60q", event)
+	}
+	img, err := png.Decode(base64.NewDecoder(base64.StdEncoding, strings.NewReader(encoded)))
+	if err != nil {
+		t.Fatalf("frame is not a PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size != image.Pt(40, 20) {
+		t.Errorf("unexpected frame size %v", size)
+	}
+}
@@ -0,0 +1,84 @@
+package painter
+
+import (
+	"context"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// Result — результат виконання операції, переданої через PostFuture.
+type Result struct {
+	// Err — причина, з якої операцію не виконано, наприклад ErrQueueFull, або помилка, яку повернула
+	// чи спричинила панікою одна з її частин.
+	Err error
+}
+
+// tracked — операція, про виконання якої треба повідомити через канал done.
+type tracked struct {
+	op   Operation
+	done chan Result
+}
+
+func (t *tracked) Do(screen.Texture) bool { return false }
+
+func (t *tracked) resolve(err error) {
+	t.done <- Result{Err: err}
+}
+
+// PostFuture додає операцію в чергу і повертає канал, з якого можна прочитати результат один раз.
+// Результат з'являється, коли операцію виконано, а якщо вона оновлює текстуру — коли кадр з нею
+// передано у Receiver і Update повернувся. Кадр, відкладений через MaxFPS, вважається показаним
+// разом з новішим кадром, який його замінив.
+func (l *Loop) PostFuture(op Operation) <-chan Result {
+	done, err := l.PostTracked(op)
+	if err != nil {
+		failed := make(chan Result, 1)
+		failed <- Result{Err: err}
+		return failed
+	}
+	return done
+}
+
+// PostTracked працює як PostFuture, але помилку Post повертає одразу, а канал — лише для операції,
+// яку прийнято в чергу. Так викликач може змінити свій стан лише тоді, коли операцію прийнято.
+func (l *Loop) PostTracked(op Operation) (<-chan Result, error) {
+	t := &tracked{op: op, done: make(chan Result, 1)}
+	if err := l.Post(t); err != nil {
+		return nil, err
+	}
+	return t.done, nil
+}
+
+// PostAndWait додає операцію в чергу і чекає, доки її буде виконано і показано, як у PostFuture.
+// Якщо ctx завершується раніше, повертається його помилка, але операція лишається в черзі.
+func (l *Loop) PostAndWait(ctx context.Context, op Operation) error {
+	done := l.PostFuture(op)
+	select {
+	case r := <-done:
+		return r.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// doTracked виконує операцію з майбутнім результатом. Якщо вона оновлює текстуру, результат
+// відкладається до показу кадру.
+func (l *Loop) doTracked(t *tracked) bool {
+	l.opErr = nil
+	ready := l.do(t.op)
+	if err := l.opErr; err != nil || !ready {
+		l.opErr = nil
+		t.resolve(err)
+		return ready
+	}
+	l.waiting = append(l.waiting, t)
+	return true
+}
+
+// presented повідомляє операції, що чекали на показ кадру.
+func (l *Loop) presented() {
+	for _, t := range l.waiting {
+		t.resolve(nil)
+	}
+	l.waiting = nil
+}
@@ -0,0 +1,101 @@
+package painter
+
+import (
+	"context"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"golang.org/x/exp/shiny/screen"
+)
+
+// slowReceiver рахує кадри та затримує кожен виклик Update.
+type slowReceiver struct {
+	frames chan struct{}
+}
+
+func (r *slowReceiver) Update(t screen.Texture) {
+	time.Sleep(20 * time.Millisecond)
+	r.frames <- struct{}{}
+}
+
+func TestLoop_PostFuture(t *testing.T) {
+	rec := &slowReceiver{frames: make(chan struct{}, 10)}
+	l := Loop{Receiver: rec}
+	startLoop(t, &l, offscreen.NewScreen())
+
+	executed := false
+	res := <-l.PostFuture(OperationFunc(func(screen.Texture) { executed = true }))
+	if res.Err != nil || !executed {
+		t.Errorf("operation must be executed before the result, got %+v", res)
+	}
+
+	res = <-l.PostFuture(OperationList{BackgroundOp(color.White), UpdateOp})
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+	select {
+	case <-rec.frames:
+	default:
+		t.Error("update must resolve only after Receiver.Update returned")
+	}
+}
+
+func TestLoop_PostAndWaitMaxFPS(t *testing.T) {
+	rec := &FrameRecorder{}
+	l := Loop{Receiver: rec, MaxFPS: 10}
+	startLoop(t, &l, offscreen.NewScreen())
+
+	ctx := context.Background()
+	if err := l.PostAndWait(ctx, OperationList{BackgroundOp(color.Black), UpdateOp}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Другий кадр відкладається через обмеження частоти, але результат з'являється після його показу.
+	if err := l.PostAndWait(ctx, OperationList{BackgroundOp(color.White), UpdateOp}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rec.Frame().RGBAAt(0, 0); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("frame must be presented when PostAndWait returns, got %v", got)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	release := make(chan struct{})
+	defer close(release)
+	_ = l.Post(OperationFunc(func(screen.Texture) { <-release }))
+	if err := l.PostAndWait(ctx, UpdateOp); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline error, got %v", err)
+	}
+}
+
+func TestLoop_PostFutureDropped(t *testing.T) {
+	release := make(chan struct{})
+	l := blockLoop(t, 1, OverflowReject, release)
+
+	first := l.PostFuture(UpdateOp)
+	if res := <-l.PostFuture(UpdateOp); res.Err != ErrQueueFull {
+		t.Errorf("expected ErrQueueFull, got %v", res.Err)
+	}
+	close(release)
+	if res := <-first; res.Err != nil {
+		t.Errorf("unexpected error: %v", res.Err)
+	}
+}
+
+func TestLoop_PostTracked(t *testing.T) {
+	release := make(chan struct{})
+	l := blockLoop(t, 1, OverflowReject, release)
+
+	first, err := l.PostTracked(UpdateOp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if done, err := l.PostTracked(UpdateOp); err != ErrQueueFull || done != nil {
+		t.Errorf("expected ErrQueueFull without a result channel, got %v", err)
+	}
+	close(release)
+	if res := <-first; res.Err != nil {
+		t.Errorf("unexpected error: %v", res.Err)
+	}
+}
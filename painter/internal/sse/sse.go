@@ -0,0 +1,64 @@
+// Package sse записує потоки Server-Sent Events для обробників кадрів і подій сцени.
+package sse
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Інтервал коментарів, які підтримують з'єднання потоку, коли подій немає.
+const keepAliveInterval = 15 * time.Second
+
+// Stream записує події Server-Sent Events і одразу надсилає їх клієнту.
+type Stream struct {
+	// KeepAlive спрацьовує, коли час надіслати коментар, щоб з'єднання не закрилось.
+	KeepAlive <-chan time.Time
+
+	rw     http.ResponseWriter
+	rc     *http.ResponseController
+	ticker *time.Ticker
+}
+
+// Start перевіряє метод запиту та надсилає заголовки потоку. Тайм-аут запису сервера знімається,
+// бо потік триває, доки клієнт не від'єднається.
+func Start(rw http.ResponseWriter, r *http.Request) (*Stream, bool) {
+	if r.Method != http.MethodGet {
+		rw.Header().Set("Allow", http.MethodGet)
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	rc := http.NewResponseController(rw)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return nil, false
+	}
+	ticker := time.NewTicker(keepAliveInterval)
+	return &Stream{KeepAlive: ticker.C, rw: rw, rc: rc, ticker: ticker}, true
+}
+
+// Send надсилає подію event з даними data.
+func (s *Stream) Send(event string, data []byte) error {
+	if _, err := fmt.Fprintf(s.rw, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// Comment надсилає коментар, який клієнт ігнорує.
+func (s *Stream) Comment() error {
+	if _, err := fmt.Fprint(s.rw, ": keep-alive\n\n"); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// Stop зупиняє таймер KeepAlive.
+func (s *Stream) Stop() {
+	s.ticker.Stop()
+}
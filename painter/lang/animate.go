@@ -0,0 +1,94 @@
+package lang
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// Найбільша тривалість анімації.
+const maxAnimationDuration = time.Hour
+
+// parseAnimate розбирає команду animate <id> to|by x y over <duration> [easing]. Після to задається
+// нормалізована точка, куди переміститься точка прив'язки фігури, після by — нормалізоване зміщення.
+func (p *Parser) parseAnimate(cmd command, s *CurState, size image.Point) (painter.Operation, *SyntaxError) {
+	if err := cmd.arity(6, 7); err != nil {
+		return nil, err
+	}
+	id, mode, over := cmd.args[0], cmd.args[1], cmd.args[4]
+
+	shape, ok := s.Figures.Get(id.text)
+	if !ok {
+		return nil, cmd.errorAt(id, "no figure with id "+id.text)
+	}
+	if mode.text != "to" && mode.text != "by" {
+		return nil, cmd.errorAt(mode, `expected "to" or "by"`)
+	}
+	vals, err := cmd.floats(cmd.args[2:4])
+	if err != nil {
+		return nil, err
+	}
+	if over.text != "over" {
+		return nil, cmd.errorAt(over, `expected "over"`)
+	}
+
+	dur, parseErr := time.ParseDuration(cmd.args[5].text)
+	if parseErr != nil || dur <= 0 || dur > maxAnimationDuration {
+		return nil, cmd.errorAt(cmd.args[5], fmt.Sprintf("duration must be like 2s or 500ms, up to %s", maxAnimationDuration))
+	}
+
+	ease := painter.Linear
+	if len(cmd.args) == 7 {
+		e, ok := painter.Easings[cmd.args[6].text]
+		if !ok {
+			return nil, cmd.errorAt(cmd.args[6], "unknown easing: "+cmd.args[6].text)
+		}
+		ease = e
+	}
+
+	delta := image.Pt(int(vals[0]*float64(size.X)), int(vals[1]*float64(size.Y)))
+	if mode.text == "to" {
+		delta = delta.Sub(shape.Anchor())
+	}
+
+	return painter.AnimateOp{Animation: painter.Animation{
+		ID:       id.text,
+		Duration: dur,
+		Easing:   ease,
+		Step:     s.animationStep(id.text, delta),
+	}}, nil
+}
+
+// animationStep повертає крок анімації, який зсуває фігуру id на частку progress від delta у постійному
+// стані сцени та перемальовує її. Якщо фігуру видалено, анімація завершується.
+func (s *CurState) animationStep(id string, delta image.Point) func(progress float64) painter.Operation {
+	live, mu := s.live, s.mu
+	var applied image.Point
+
+	return func(progress float64) painter.Operation {
+		mu.Lock()
+		defer mu.Unlock()
+
+		shape, ok := live.Figures.Get(id)
+		if !ok {
+			return nil
+		}
+		want := image.Pt(int(math.Round(float64(delta.X)*progress)), int(math.Round(float64(delta.Y)*progress)))
+		d := want.Sub(applied)
+		applied = want
+		moved := shape.Moved(d.X, d.Y)
+		live.Figures.Set(id, moved)
+		if d != (image.Point{}) {
+			live.hub.publish([]SceneEvent{shapeEvent(EventMoved, id, moved)})
+		}
+
+		ops := buildOps(live)
+		if live.UpdateOp == nil {
+			ops = append(ops, painter.UpdateOp)
+		}
+		return painter.OperationList(ops)
+	}
+}
@@ -0,0 +1,151 @@
+package lang_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+// animation повертає анімацію з операцій ops.
+func animation(t *testing.T, ops []painter.Operation) painter.Animation {
+	t.Helper()
+	for _, op := range ops {
+		if a, ok := op.(painter.AnimateOp); ok {
+			return a.Animation
+		}
+	}
+	t.Fatalf("expected animate operation in %v", ops)
+	return painter.Animation{}
+}
+
+// drawnFigure повертає хрест, який малює крок анімації.
+func drawnFigure(t *testing.T, op painter.Operation) *painter.Figure {
+	t.Helper()
+	list, _ := op.(painter.OperationList)
+	for _, op := range list {
+		if fig, ok := op.(*painter.Figure); ok {
+			return fig
+		}
+	}
+	t.Fatalf("expected figure in animation frame %v", op)
+	return nil
+}
+
+func TestParseAnimate(t *testing.T) {
+	session := lang.NewSession()
+	parser := &lang.Parser{}
+
+	if _, err := session.Parse(parser, strings.NewReader("figure a 0.1 0.1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ops, err := session.Parse(parser, strings.NewReader("animate a to 0.5 0.6 over 2s ease-in-out"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	a := animation(t, ops)
+	if a.ID != "a" || a.Duration != 2*time.Second || a.Easing == nil {
+		t.Fatalf("unexpected animation %+v", a)
+	}
+
+	if fig := drawnFigure(t, a.Step(0.5)); fig.X != 120 || fig.Y != 140 {
+		t.Errorf("figure expected half way at (120,140), got (%d,%d)", fig.X, fig.Y)
+	}
+	if fig := drawnFigure(t, a.Step(1)); fig.X != 200 || fig.Y != 240 {
+		t.Errorf("figure expected at (200,240), got (%d,%d)", fig.X, fig.Y)
+	}
+
+	// Анімація змінює стан сесії, тож наступні скрипти бачать фігуру на новому місці.
+	ops, err = session.Parse(parser, strings.NewReader("move a 0.1 0"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fig := drawnFigure(t, painter.OperationList(ops)); fig.X != 240 || fig.Y != 240 {
+		t.Errorf("figure expected at (240,240), got (%d,%d)", fig.X, fig.Y)
+	}
+
+	ops, err = session.Parse(parser, strings.NewReader("animate a by -0.1 0 over 500ms\ndelete a"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if op := animation(t, ops).Step(1); op != nil {
+		t.Errorf("animation of deleted figure must end, got %v", op)
+	}
+
+	ops, err = session.Parse(parser, strings.NewReader("figure b 0 0\nstop b"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if op, ok := ops[len(ops)-1].(painter.StopAnimationOp); !ok || op.ID != "b" {
+		t.Errorf("expected stop operation, got %v", ops[len(ops)-1])
+	}
+}
+
+func TestParseAnimateErrors(t *testing.T) {
+	session := lang.NewSession()
+	parser := &lang.Parser{}
+	if _, err := session.Parse(parser, strings.NewReader("figure a 0.1 0.1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, bad := range []string{
+		"animate x to 0.5 0.5 over 1s",
+		"animate a at 0.5 0.5 over 1s",
+		"animate a to 0.5 0.5 in 1s",
+		"animate a to 0.5 0.5 over 2",
+		"animate a to 0.5 0.5 over -1s",
+		"animate a to 0.5 0.5 over 1s bounce",
+		"animate a to 0.5 over 1s",
+		"stop x",
+	} {
+		if _, err := session.Parse(parser, strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestJSONParser_Animate(t *testing.T) {
+	session := lang.NewSession()
+	jp := &lang.JSONParser{Parser: &lang.Parser{}}
+
+	ops, err := session.Parse(jp, strings.NewReader(`[
+		{"op": "figure", "id": "a", "x": 0, "y": 0},
+		{"op": "animate", "id": "a", "to": [0.5, 0.5], "over": "1s", "ease": "ease-out"}
+	]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fig := drawnFigure(t, animation(t, ops).Step(1)); fig.X != 200 || fig.Y != 200 {
+		t.Errorf("figure expected at (200,200), got (%d,%d)", fig.X, fig.Y)
+	}
+}
+
+func TestParseAnimate_PlainState(t *testing.T) {
+	loop, _ := startLoop(t)
+	state := lang.UpdateState()
+	parser := &lang.Parser{}
+
+	ops, err := parser.Parse(strings.NewReader("figure a 0.1 0.1\nanimate a by 0.5 0.5 over 100ms\nupdate"), state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loop.Post(painter.OperationList(ops))
+
+	// Анімація змінює стан у горутині Loop, поки Parse продовжує працювати з ним.
+	deadline := time.Now().Add(150 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if _, err := parser.Parse(strings.NewReader("figure b 0.5 0.5\ndelete b\nupdate"), state); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := loop.WaitAnimations(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state.Figures.Get("a"); !ok {
+		t.Error("animated figure must stay in the state")
+	}
+}
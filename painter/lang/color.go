@@ -0,0 +1,105 @@
+package lang
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Palette зіставляє назви кольорів зі значеннями. Назви порівнюються без урахування регістру.
+type Palette map[string]color.Color
+
+// DefaultPalette використовується, якщо назви немає у палітрі Parser.
+var DefaultPalette = Palette{
+	"white":  color.White,
+	"black":  color.Black,
+	"green":  color.RGBA{G: 255, A: 255},
+	"red":    color.RGBA{R: 255, A: 255},
+	"blue":   color.RGBA{B: 255, A: 255},
+	"yellow": color.RGBA{R: 255, G: 230, B: 69, A: 255},
+	"gray":   color.RGBA{R: 128, G: 128, B: 128, A: 255},
+	"orange": color.RGBA{R: 255, G: 165, A: 255},
+	"purple": color.RGBA{R: 128, B: 128, A: 255},
+	"cyan":   color.RGBA{G: 255, B: 255, A: 255},
+}
+
+// ParseColor розбирає колір у форматі #rrggbb, #rrggbbaa, rgb(r,g,b), rgba(r,g,b,a) або назву з палітри p.
+func ParseColor(s string, p Palette) (color.Color, error) {
+	switch {
+	case strings.HasPrefix(s, "#"):
+		return parseHexColor(s)
+	case strings.HasPrefix(s, "rgb(") || strings.HasPrefix(s, "rgba("):
+		return parseRGBColor(s)
+	}
+
+	if c, ok := p.lookup(s); ok {
+		return c, nil
+	}
+	if c, ok := DefaultPalette.lookup(s); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown color: %s", s)
+}
+
+// lookup шукає колір за назвою без урахування регістру, тож ключі палітри можуть мати будь-який регістр.
+func (p Palette) lookup(name string) (color.Color, bool) {
+	if c, ok := p[name]; ok {
+		return c, true
+	}
+	for key, c := range p {
+		if strings.EqualFold(key, name) {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+func parseHexColor(s string) (color.Color, error) {
+	hex := s[1:]
+	if len(hex) != 6 && len(hex) != 8 {
+		return nil, fmt.Errorf("invalid hex color: %s", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex color: %s", s)
+	}
+	if len(hex) == 6 {
+		v = v<<8 | 0xff
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+func parseRGBColor(s string) (color.Color, error) {
+	open := strings.IndexByte(s, '(')
+	if !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("invalid color: %s", s)
+	}
+	parts := strings.Split(s[open+1:len(s)-1], ",")
+	want := 3
+	if s[:open] == "rgba" {
+		want = 4
+	}
+	if len(parts) != want {
+		return nil, fmt.Errorf("invalid color: %s, expected %d components", s, want)
+	}
+
+	c := color.NRGBA{A: 255}
+	for i, part := range parts {
+		v, err := strconv.ParseUint(strings.TrimSpace(part), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color component %q in %s", part, s)
+		}
+		switch i {
+		case 0:
+			c.R = uint8(v)
+		case 1:
+			c.G = uint8(v)
+		case 2:
+			c.B = uint8(v)
+		case 3:
+			c.A = uint8(v)
+		}
+	}
+	return c, nil
+}
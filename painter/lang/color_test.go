@@ -0,0 +1,47 @@
+package lang_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func TestParseColor(t *testing.T) {
+	palette := lang.Palette{
+		"brand":     color.RGBA{R: 1, G: 2, B: 3, A: 255},
+		"DarkBrand": color.RGBA{R: 4, G: 5, B: 6, A: 255},
+	}
+
+	tests := []struct {
+		in   string
+		want color.Color
+	}{
+		{"#ff8000", color.NRGBA{R: 255, G: 128, A: 255}},
+		{"#ff800080", color.NRGBA{R: 255, G: 128, A: 128}},
+		{"rgb(1,2,3)", color.NRGBA{R: 1, G: 2, B: 3, A: 255}},
+		{"rgba(1,2,3,4)", color.NRGBA{R: 1, G: 2, B: 3, A: 4}},
+		{"brand", color.RGBA{R: 1, G: 2, B: 3, A: 255}},
+		{"BRAND", color.RGBA{R: 1, G: 2, B: 3, A: 255}},
+		{"DarkBrand", color.RGBA{R: 4, G: 5, B: 6, A: 255}},
+		{"darkbrand", color.RGBA{R: 4, G: 5, B: 6, A: 255}},
+		{"White", color.White},
+		{"green", color.RGBA{G: 255, A: 255}},
+	}
+	for _, tc := range tests {
+		got, err := lang.ParseColor(tc.in, palette)
+		if err != nil {
+			t.Errorf("ParseColor(%q) unexpected error: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseColor(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+
+	for _, in := range []string{"#fff", "#gg0000", "rgb(1,2)", "rgb(1,2,300)", "nocolor"} {
+		if _, err := lang.ParseColor(in, palette); err == nil {
+			t.Errorf("ParseColor(%q) expected error", in)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyntaxError описує помилку у скрипті разом з позицією токена, який її спричинив.
+// Рядки та колонки нумеруються з одиниці.
+type SyntaxError struct {
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Token    string `json:"token"`
+	Expected string `json:"expected,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (e *SyntaxError) Error() string {
+	msg := fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	if e.Expected != "" {
+		msg += fmt.Sprintf(" (expected: %s)", e.Expected)
+	}
+	return msg
+}
+
+// SyntaxErrors — усі помилки, знайдені під час розбору одного скрипту.
+type SyntaxErrors []*SyntaxError
+
+func (e SyntaxErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,121 @@
+package lang
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// Типи подій сцени.
+const (
+	EventAdded   = "added"   // з'явилася нова фігура
+	EventMoved   = "moved"   // фігура змінила положення
+	EventChanged = "changed" // фігуру замінено іншою на тому ж місці
+	EventRemoved = "removed" // фігуру видалено
+	EventReset   = "reset"   // сцену очищено командою reset
+)
+
+// SceneEvent — зміна сцени, про яку Session повідомляє підписників.
+type SceneEvent struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+	// Kind — вид фігури: figure, circle, ellipse, line, polyline, polygon або text.
+	Kind string `json:"kind,omitempty"`
+	// X, Y — точка прив'язки фігури у пікселях полотна.
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Розмір буфера подій для одного підписника. Події для підписника, який не встигає їх читати, відкидаються.
+const eventBuffer = 256
+
+// eventHub розсилає події сцени підписникам, не чекаючи на повільних.
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[chan SceneEvent]struct{}
+}
+
+func (h *eventHub) subscribe() (<-chan SceneEvent, func()) {
+	ch := make(chan SceneEvent, eventBuffer)
+
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan SceneEvent]struct{})
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (h *eventHub) active() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs) > 0
+}
+
+func (h *eventHub) publish(events []SceneEvent) {
+	if h == nil || len(events) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs {
+		for _, e := range events {
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	}
+}
+
+// shapeEvent створює подію для фігури id.
+func shapeEvent(typ, id string, shape painter.Shape) SceneEvent {
+	p := shape.Anchor()
+	kind := strings.ToLower(strings.TrimPrefix(fmt.Sprintf("%T", shape), "*painter."))
+	return SceneEvent{Type: typ, ID: id, Kind: kind, X: p.X, Y: p.Y}
+}
+
+// diffScene повертає події, що перетворюють фігури стану before на фігури стану after.
+// Якщо між ними була команда reset, спершу йде подія reset, а далі лише додані фігури.
+func diffScene(before, after *CurState) []SceneEvent {
+	var events []SceneEvent
+	old := &before.Figures
+	if after.resets != before.resets {
+		events = append(events, SceneEvent{Type: EventReset})
+		old = &Figures{}
+	}
+
+	for _, id := range old.IDs() {
+		if _, ok := after.Figures.Get(id); !ok {
+			shape, _ := old.Get(id)
+			events = append(events, shapeEvent(EventRemoved, id, shape))
+		}
+	}
+	for _, id := range after.Figures.IDs() {
+		shape, _ := after.Figures.Get(id)
+		prev, ok := old.Get(id)
+		switch {
+		case !ok:
+			events = append(events, shapeEvent(EventAdded, id, shape))
+		case prev.Anchor() != shape.Anchor():
+			events = append(events, shapeEvent(EventMoved, id, shape))
+		case prev != shape && !reflect.DeepEqual(prev, shape):
+			events = append(events, shapeEvent(EventChanged, id, shape))
+		}
+	}
+	return events
+}
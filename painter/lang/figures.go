@@ -0,0 +1,112 @@
+package lang
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// Figures — впорядкований набір фігур сцени (хрестів, кіл, ліній тощо) з доступом за ідентифікатором.
+// Фігури малюються у порядку додавання. Нульове значення готове до використання.
+type Figures struct {
+	ids  []string
+	byID map[string]painter.Shape
+	seq  int // лічильник для автоматичних ідентифікаторів
+}
+
+// Set додає фігуру з ідентифікатором id у кінець набору або замінює існуючу, зберігаючи її місце.
+func (f *Figures) Set(id string, fig painter.Shape) {
+	if f.byID == nil {
+		f.byID = make(map[string]painter.Shape)
+	}
+	if _, ok := f.byID[id]; !ok {
+		f.ids = append(f.ids, id)
+	}
+	f.byID[id] = fig
+}
+
+// Add додає фігуру з новим автоматично згенерованим ідентифікатором і повертає його.
+func (f *Figures) Add(fig painter.Shape) string {
+	for {
+		f.seq++
+		id := "fig" + strconv.Itoa(f.seq)
+		if _, ok := f.byID[id]; !ok {
+			f.Set(id, fig)
+			return id
+		}
+	}
+}
+
+func (f *Figures) Get(id string) (painter.Shape, bool) {
+	fig, ok := f.byID[id]
+	return fig, ok
+}
+
+// Delete видаляє фігуру та повертає false, якщо її не було.
+func (f *Figures) Delete(id string) bool {
+	if _, ok := f.byID[id]; !ok {
+		return false
+	}
+	delete(f.byID, id)
+	for i, v := range f.ids {
+		if v == id {
+			f.ids = append(f.ids[:i:i], f.ids[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func (f *Figures) Len() int { return len(f.ids) }
+
+// IDs повертає ідентифікатори фігур у порядку малювання.
+func (f *Figures) IDs() []string { return append([]string(nil), f.ids...) }
+
+// Match повертає ідентифікатори фігур, що відповідають шаблону у синтаксисі path.Match.
+// Шаблон без спеціальних символів, для якого немає фігури, вважається помилкою.
+func (f *Figures) Match(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid figure pattern: %s", pattern)
+	}
+
+	var res []string
+	for _, id := range f.ids {
+		if ok, _ := path.Match(pattern, id); ok {
+			res = append(res, id)
+		}
+	}
+	if len(res) == 0 && !isPattern(pattern) {
+		return nil, fmt.Errorf("unknown figure: %s", pattern)
+	}
+	return res, nil
+}
+
+// clone повертає копію набору. Самі фігури не змінюються, тож копіювати їх не потрібно.
+func (f *Figures) clone() Figures {
+	res := Figures{ids: append([]string(nil), f.ids...), seq: f.seq}
+	if f.byID != nil {
+		res.byID = make(map[string]painter.Shape, len(f.byID))
+		for id, fig := range f.byID {
+			res.byID[id] = fig
+		}
+	}
+	return res
+}
+
+// validFigureID перевіряє, що id не можна сплутати з числом або шаблоном.
+func validFigureID(id string) error {
+	if _, err := strconv.ParseFloat(id, 64); err == nil {
+		return fmt.Errorf("figure id must not be a number: %s", id)
+	}
+	if isPattern(id) {
+		return fmt.Errorf("figure id must not contain pattern characters: %s", id)
+	}
+	return nil
+}
+
+func isPattern(s string) bool {
+	return strings.ContainsAny(s, `*?[\`)
+}
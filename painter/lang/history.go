@@ -0,0 +1,103 @@
+package lang
+
+import (
+	"errors"
+	"image"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// DefaultHistoryLimit — кількість знімків, які зберігає History, якщо Limit не задано.
+const DefaultHistoryLimit = 100
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// History зберігає обмежену кількість знімків стану сцени для команд undo та redo.
+type History struct {
+	// Limit — найбільша кількість знімків для скасування. Найстаріші знімки відкидаються.
+	Limit int
+
+	undo []*CurState
+	redo []*CurState
+}
+
+func (h *History) limit() int {
+	if h.Limit > 0 {
+		return h.Limit
+	}
+	return DefaultHistoryLimit
+}
+
+// record запам'ятовує стан s перед його зміною. Після нової зміни повторити скасовані вже не можна.
+func (h *History) record(s *CurState) {
+	h.undo = append(h.undo, s.snapshot())
+	if extra := len(h.undo) - h.limit(); extra > 0 {
+		h.undo = append([]*CurState(nil), h.undo[extra:]...)
+	}
+	h.redo = nil
+}
+
+// CanUndo та CanRedo повертають кількість кроків, які можна скасувати або повторити.
+func (h *History) CanUndo() int { return len(h.undo) }
+func (h *History) CanRedo() int { return len(h.redo) }
+
+func (h *History) clone() History {
+	return History{
+		Limit: h.Limit,
+		undo:  append([]*CurState(nil), h.undo...),
+		redo:  append([]*CurState(nil), h.redo...),
+	}
+}
+
+// snapshot повертає копію стану без історії.
+func (s *CurState) snapshot() *CurState {
+	history := s.History
+	s.History = History{}
+	res := s.clone()
+	s.History = history
+	return res
+}
+
+// undo скасовує до n останніх змін стану s і повертає операції, що перемальовують відновлену сцену.
+func undo(s *CurState, n int) ([]painter.Operation, error) {
+	if s.History.CanUndo() == 0 {
+		return nil, ErrNothingToUndo
+	}
+	return s.travel(n, &s.History.undo, &s.History.redo), nil
+}
+
+// redo повторює до n скасованих змін стану s.
+func redo(s *CurState, n int) ([]painter.Operation, error) {
+	if s.History.CanRedo() == 0 {
+		return nil, ErrNothingToRedo
+	}
+	return s.travel(n, &s.History.redo, &s.History.undo), nil
+}
+
+// travel переносить поточний стан у стек to і відновлює стан зі стека from n разів.
+func (s *CurState) travel(n int, from, to *[]*CurState) []painter.Operation {
+	size := s.Size
+	for i := 0; i < n && len(*from) > 0; i++ {
+		last := len(*from) - 1
+		restored := (*from)[last]
+		*from = (*from)[:last]
+		*to = append(*to, s.snapshot())
+
+		history := s.History
+		*s = *restored.clone()
+		s.History = history
+	}
+
+	var ops []painter.Operation
+	if s.Size != size && s.Size != (image.Point{}) {
+		ops = append(ops, painter.CanvasOp{Size: s.Size})
+	}
+	ops = append(ops, buildOps(s)...)
+	if s.UpdateOp == nil {
+		ops = append(ops, painter.UpdateOp)
+	}
+	return ops
+}
@@ -0,0 +1,58 @@
+package lang
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// UndoHandler конструює обробник POST запитів, який скасовує останні зміни сцени сесії s.
+// Кількість кроків задається параметром n, за замовчуванням один. Параметр wait=true працює як у Handler.
+func UndoHandler(loop *painter.Loop, s *Session) http.Handler {
+	return historyHandler(loop, s, "undo", undo)
+}
+
+// RedoHandler конструює обробник POST запитів, який повторює скасовані зміни сцени сесії s.
+func RedoHandler(loop *painter.Loop, s *Session) http.Handler {
+	return historyHandler(loop, s, "redo", redo)
+}
+
+// historyHandler у журналі сесії записує запит як текстову команду name, щоб його можна було відтворити.
+func historyHandler(loop *painter.Loop, s *Session, name string, travel func(s *CurState, n int) ([]painter.Operation, error)) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			rw.Header().Set("Allow", http.MethodPost)
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		n := 1
+		if v := r.URL.Query().Get("n"); v != "" {
+			var err error
+			if n, err = strconv.Atoi(v); err != nil || n <= 0 {
+				http.Error(rw, "n must be a positive integer", http.StatusBadRequest)
+				return
+			}
+		}
+		wait, ok := waitParam(rw, r)
+		if !ok {
+			return
+		}
+
+		p := &poster{loop: loop, wait: wait}
+		err := s.commit(r, "", fmt.Sprintf("%s %d", name, n), func(state *CurState) ([]painter.Operation, error) {
+			return travel(state, n)
+		}, p.post)
+		if p.err != nil {
+			writePostError(rw, p.err)
+			return
+		}
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusConflict)
+			return
+		}
+		p.respond(rw, r)
+	})
+}
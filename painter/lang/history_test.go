@@ -0,0 +1,123 @@
+package lang_test
+
+import (
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func TestParseUndoRedo(t *testing.T) {
+	state := lang.UpdateState()
+	parser := &lang.Parser{}
+
+	input := `figure a 0.1 0.1
+move a 0.1 0
+move a 0.1 0
+undo 2`
+	ops, err := parser.Parse(strings.NewReader(input), state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ops[len(ops)-1] != painter.UpdateOp {
+		t.Error("undo must end with update to re-render the restored scene")
+	}
+
+	a, _ := figureByID(state, "a")
+	if a.X != 40 {
+		t.Errorf("figure a expected at x=40 after undo, got %d", a.X)
+	}
+
+	if _, err := parser.Parse(strings.NewReader("redo"), state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a, _ = figureByID(state, "a")
+	if a.X != 80 {
+		t.Errorf("figure a expected at x=80 after redo, got %d", a.X)
+	}
+
+	if _, err := parser.Parse(strings.NewReader("figure b 0.5 0.5\nredo"), state); err == nil {
+		t.Error("expected error: a new change must clear redo history")
+	}
+
+	if _, err := parser.Parse(strings.NewReader("reset\nundo"), state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Figures.Len() != 1 {
+		t.Errorf("undo after reset must restore figures, got %d", state.Figures.Len())
+	}
+}
+
+func TestUndoCanvas(t *testing.T) {
+	state := lang.UpdateState()
+	parser := &lang.Parser{}
+
+	ops, err := parser.Parse(strings.NewReader("canvas 800 200\nundo"), state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var last painter.CanvasOp
+	for _, op := range ops {
+		if c, ok := op.(painter.CanvasOp); ok {
+			last = c
+		}
+	}
+	if last.Size != painter.DefaultCanvasSize || state.Size != painter.DefaultCanvasSize {
+		t.Errorf("undo must restore default canvas, got op %v and state %v", last.Size, state.Size)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	state := lang.UpdateState()
+	state.History.Limit = 2
+	parser := &lang.Parser{}
+
+	if _, err := parser.Parse(strings.NewReader("white\ngreen\nwhite\nbg red"), state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := state.History.CanUndo(); n != 2 {
+		t.Errorf("expected history to keep 2 steps, got %d", n)
+	}
+}
+
+func TestUndoHandler(t *testing.T) {
+	loop, _ := startLoop(t)
+	session := lang.NewSession()
+	if _, err := session.Parse(&lang.Parser{}, strings.NewReader("canvas 100 100\nfigure 0.5 0.5")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	undo := lang.UndoHandler(loop, session)
+	redo := lang.RedoHandler(loop, session)
+
+	resp := httptest.NewRecorder()
+	undo.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/undo?n=5", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+
+	resp = httptest.NewRecorder()
+	undo.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/undo", nil))
+	if resp.Code != http.StatusConflict {
+		t.Errorf("expected 409 when nothing to undo, got %d", resp.Code)
+	}
+
+	resp = httptest.NewRecorder()
+	redo.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/redo", nil))
+	if resp.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", resp.Code)
+	}
+
+	ops, err := session.Redo(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c, ok := ops[0].(painter.CanvasOp); !ok || c.Size != image.Pt(100, 100) {
+		t.Errorf("redo must restore canvas size first, got %#v", ops[0])
+	}
+}
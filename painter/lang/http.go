@@ -1,34 +1,181 @@
 package lang
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/roman-mazur/architecture-lab-3/painter"
 )
 
+// Найбільший розмір тіла запиту зі скриптом у байтах. Більші запити отримують 413.
+const maxScriptSize = 1 << 20
+
 // HttpHandler конструює обробник HTTP запитів, який дані з запиту віддає у Parser, а потім відправляє отриманий список
-// операцій у painter.Loop.
+// операцій у painter.Loop. Стан сцени зберігається у сесії s і переживає окремі запити.
+// Запити з типом application/json розбирає JSONParser, решту — текстовий Parser.
+func HttpHandler(loop *painter.Loop, p *Parser, s *Session) *Handler {
+	return &Handler{
+		Loop:    loop,
+		Session: s,
+		Default: p,
+		Decoders: map[string]Decoder{
+			"application/json": &JSONParser{Parser: p},
+		},
+	}
+}
 
-func HttpHandler(loop *painter.Loop, p *Parser) http.Handler {
-	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		var in io.Reader = r.Body
-		var state = UpdateState()
+// Handler приймає команди через HTTP. Декодер обирається за типом вмісту запиту.
+// Якщо вхідні дані містять помилки, клієнт отримує 400 та JSON зі списком усіх помилок.
+// Клієнт, чий скрипт більший за maxScriptSize, отримує 413.
+// Якщо черга Loop заповнена, клієнт отримує 429 або 503, а стан сесії не змінюється, тож запит можна повторити.
+// З параметром wait=true відповідь надсилається лише тоді, коли кадр показано.
+type Handler struct {
+	Loop    *painter.Loop
+	Session *Session
 
-		if r.Method == http.MethodGet {
-			in = strings.NewReader(r.URL.Query().Get("cmd"))
-		}
+	// Decoders зіставляє тип вмісту (без параметрів) з декодером.
+	Decoders map[string]Decoder
+	// Default розбирає запити з невідомим типом вмісту та GET запити з параметром cmd.
+	Default Decoder
+}
 
-		cmds, err := p.Parse(in, state)
+func (h *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	wait, ok := waitParam(rw, r)
+	if !ok {
+		return
+	}
+
+	var script string
+	contentType := r.Header.Get("Content-Type")
+
+	if r.Method == http.MethodGet {
+		script, contentType = r.URL.Query().Get("cmd"), ""
+	} else {
+		body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, maxScriptSize))
 		if err != nil {
-			log.Printf("Bad script: %s", err)
-			rw.WriteHeader(http.StatusBadRequest)
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(rw, fmt.Sprintf("script is larger than %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		script = string(body)
+	}
+
+	decoder := h.decoder(contentType)
+	p := &poster{loop: h.Loop, wait: wait}
+	err := h.Session.commit(r, contentType, script, func(state *CurState) ([]painter.Operation, error) {
+		return decoder.Parse(strings.NewReader(script), state)
+	}, p.post)
+	if p.err != nil {
+		writePostError(rw, p.err)
+		return
+	}
+	if err != nil {
+		log.Printf("Bad script: %s", err)
+		writeErrors(rw, err)
+		return
+	}
+	p.respond(rw, r)
+}
+
+// waitParam читає параметр wait до зміни стану сцени. Якщо він некоректний, клієнт отримує 400.
+func waitParam(rw http.ResponseWriter, r *http.Request) (wait, ok bool) {
+	if !r.URL.Query().Has("wait") {
+		return false, true
+	}
+	wait, err := strconv.ParseBool(r.URL.Query().Get("wait"))
+	if err != nil {
+		http.Error(rw, "wait must be true or false", http.StatusBadRequest)
+		return false, false
+	}
+	return wait, true
+}
+
+// poster передає операції запиту у loop. Якщо wait, відповідь надсилається після показу кадру.
+type poster struct {
+	loop *painter.Loop
+	wait bool
+
+	done <-chan painter.Result
+	err  error // помилка, з якою loop не прийняв операції
+}
+
+func (p *poster) post(ops []painter.Operation) error {
+	if p.wait {
+		p.done, p.err = p.loop.PostTracked(painter.OperationList(ops))
+	} else {
+		p.err = p.loop.Post(painter.OperationList(ops))
+	}
+	return p.err
+}
+
+// respond відповідає клієнту, чиї операції прийняв loop.
+func (p *poster) respond(rw http.ResponseWriter, r *http.Request) {
+	if p.done != nil {
+		select {
+		case res := <-p.done:
+			if res.Err != nil {
+				writePostError(rw, res.Err)
+				return
+			}
+		case <-r.Context().Done():
+			writePostError(rw, r.Context().Err())
 			return
 		}
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+// writePostError відповідає клієнту, чиї операції не прийняв Loop.
+func writePostError(rw http.ResponseWriter, err error) {
+	log.Printf("Operations not posted: %s", err)
+	switch {
+	case errors.Is(err, painter.ErrQueueFull):
+		rw.Header().Set("Retry-After", "1")
+		http.Error(rw, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, context.DeadlineExceeded):
+		http.Error(rw, err.Error(), http.StatusGatewayTimeout)
+	default:
+		http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+	}
+}
+
+func (h *Handler) decoder(contentType string) Decoder {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if d, ok := h.Decoders[mediaType]; ok {
+			return d
+		}
+	}
+	return h.Default
+}
+
+// errorResponse — тіло відповіді з помилками розбору скрипту.
+type errorResponse struct {
+	Errors []*SyntaxError `json:"errors"`
+}
+
+func writeErrors(rw http.ResponseWriter, err error) {
+	var resp errorResponse
+
+	var syntaxErrs SyntaxErrors
+	if errors.As(err, &syntaxErrs) {
+		resp.Errors = syntaxErrs
+	} else {
+		resp.Errors = []*SyntaxError{{Message: err.Error()}}
+	}
 
-		loop.Post(painter.OperationList(cmds))
-		rw.WriteHeader(http.StatusOK)
-	})
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(rw).Encode(resp)
 }
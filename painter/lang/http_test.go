@@ -0,0 +1,177 @@
+package lang_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"golang.org/x/exp/shiny/screen"
+)
+
+func startLoop(t *testing.T) (*painter.Loop, *painter.FrameRecorder) {
+	t.Helper()
+
+	rec := &painter.FrameRecorder{}
+	loop := &painter.Loop{Receiver: rec}
+	loop.Start(offscreen.NewScreen())
+	t.Cleanup(func() { _ = loop.StopAndWait(context.Background()) })
+	return loop, rec
+}
+
+// blockedLoop запускає loop з чергою на одну операцію і займає його операцією, яка чекає на виклик unblock.
+func blockedLoop(t *testing.T) (loop *painter.Loop, rec *painter.FrameRecorder, unblock func()) {
+	t.Helper()
+
+	rec = &painter.FrameRecorder{}
+	loop = &painter.Loop{Receiver: rec, QueueSize: 1, Overflow: painter.OverflowReject}
+	loop.Start(offscreen.NewScreen())
+	t.Cleanup(func() { _ = loop.StopAndWait(context.Background()) })
+
+	release, started := make(chan struct{}), make(chan struct{})
+	_ = loop.Post(painter.OperationFunc(func(screen.Texture) {
+		close(started)
+		<-release
+	}))
+	<-started
+	unblock = sync.OnceFunc(func() { close(release) })
+	t.Cleanup(unblock)
+	return loop, rec, unblock
+}
+
+func TestHttpHandler_SyntaxErrors(t *testing.T) {
+	loop, _ := startLoop(t)
+	handler := lang.HttpHandler(loop, &lang.Parser{}, lang.NewSession())
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("figure 0.1\nunknown"))
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.Code)
+	}
+	if ct := resp.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	var body struct {
+		Errors []lang.SyntaxError `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("bad JSON response: %v", err)
+	}
+	if len(body.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %+v", body.Errors)
+	}
+	if body.Errors[1].Line != 2 || body.Errors[1].Token != "unknown" {
+		t.Errorf("unexpected second error %+v", body.Errors[1])
+	}
+}
+
+func TestHttpHandler_OK(t *testing.T) {
+	loop, _ := startLoop(t)
+	handler := lang.HttpHandler(loop, &lang.Parser{}, lang.NewSession())
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?cmd=white", nil))
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.Code)
+	}
+}
+
+func TestHttpHandler_JSON(t *testing.T) {
+	loop, _ := startLoop(t)
+	session := lang.NewSession()
+	handler := lang.HttpHandler(loop, &lang.Parser{}, session)
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"op":"figure","x":0.5,"y":0.5},{"op":"update"}]`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	handler.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200 for JSON script, got %d: %s", resp.Code, resp.Body)
+	}
+
+	resp = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"op":"figure"}]`))
+	handler.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("expected JSON body without content type to be parsed as text, got %d", resp.Code)
+	}
+}
+
+func TestHttpHandler_QueueFull(t *testing.T) {
+	loop, rec, unblock := blockedLoop(t)
+	frames, stop := rec.Subscribe()
+	defer stop()
+
+	session := lang.NewSession()
+	events, cancel := session.Subscribe()
+	defer cancel()
+	handler := lang.HttpHandler(loop, &lang.Parser{}, session)
+	serve := func(script string) int {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(script)))
+		return resp.Code
+	}
+
+	if code := serve("figure a 0.5 0.5\nupdate"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	added := <-events
+	if code := serve("move a 0.1 0\nupdate"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+	if len(events) != 0 {
+		t.Fatalf("rejected script must not change the scene, got %+v", <-events)
+	}
+
+	// Повторений запит застосовується один раз.
+	unblock()
+	<-frames
+	if code := serve("move a 0.1 0\nupdate"); code != http.StatusOK {
+		t.Fatalf("expected 200 on retry, got %d", code)
+	}
+	if moved := <-events; moved.Type != lang.EventMoved || moved.X != added.X+40 {
+		t.Errorf("expected figure moved once from x=%d, got %+v", added.X, moved)
+	}
+}
+
+func TestHttpHandler_TooLarge(t *testing.T) {
+	loop, _ := startLoop(t)
+	handler := lang.HttpHandler(loop, &lang.Parser{}, lang.NewSession())
+
+	resp := httptest.NewRecorder()
+	script := strings.Repeat("# comment\n", 1<<17) + "update"
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(script)))
+	if resp.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", resp.Code)
+	}
+}
+
+func TestHttpHandler_Wait(t *testing.T) {
+	loop, rec := startLoop(t)
+	handler := lang.HttpHandler(loop, &lang.Parser{}, lang.NewSession())
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?cmd=update&wait=true", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+	if rec.Frame() == nil {
+		t.Error("frame must be presented before the response with wait=true")
+	}
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?cmd=update&wait=maybe", nil))
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid wait, got %d", resp.Code)
+	}
+}
@@ -0,0 +1,197 @@
+package lang
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// Decoder перетворює вхідні дані на операції для painter.Loop, змінюючи стан сцени s.
+type Decoder interface {
+	Parse(in io.Reader, s *CurState) ([]painter.Operation, error)
+}
+
+// JSONParser розбирає команди у форматі JSON: масив об'єктів на кшталт {"op":"figure","x":0.5,"y":0.5}.
+// Аргументи можна задати іменованими полями з jsonFields або масивом "args" у тому ж порядку, що й у текстовому
+// скрипті. Параметри фігур (fill, stroke, width) задаються однойменними полями, а точки ламаних — масивом
+// "points" з чисел або пар [x, y]. У помилках Line — це порядковий номер команди у масиві.
+type JSONParser struct {
+	Parser *Parser
+}
+
+// jsonFields задає назви полів JSON для аргументів кожної команди у порядку текстового скрипту.
+var jsonFields = map[string][]string{
+	"update":  {},
+	"white":   {},
+	"green":   {},
+	"bg":      {"color"},
+	"bgrect":  {"x1", "y1", "x2", "y2", "color"},
+	"figure":  {"id", "x", "y", "color"},
+	"move":    {"id", "dx", "dy"},
+	"animate": {"id", "to", "by", "over", "ease"},
+	"stop":    {"id"},
+	"canvas":  {"width", "height"},
+
+	"circle":   {"id", "x", "y", "r"},
+	"ellipse":  {"id", "x", "y", "rx", "ry"},
+	"line":     {"id", "x1", "y1", "x2", "y2"},
+	"polyline": {"id", "points"},
+	"polygon":  {"id", "points"},
+	"text":     {"id", "x", "y", "text"},
+	"delete":   {"id"},
+	"select":   {"id"},
+	"reset":    {},
+	"undo":     {"n"},
+	"redo":     {"n"},
+}
+
+func (jp *JSONParser) Parse(in io.Reader, s *CurState) ([]painter.Operation, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []map[string]json.RawMessage
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		data = append(append([]byte{'['}, data...), ']')
+	}
+	if err := json.Unmarshal(data, &objs); err != nil {
+		return nil, SyntaxErrors{{Message: "invalid JSON: " + err.Error()}}
+	}
+
+	var (
+		cmds []command
+		errs SyntaxErrors
+	)
+	for i, obj := range objs {
+		cmd, err := jsonCommand(obj, i+1)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	p := jp.Parser
+	if p == nil {
+		p = &Parser{}
+	}
+	return p.apply(cmds, s, errs)
+}
+
+// jsonKeywords — поля, назви яких стають словами скрипту перед значенням, як to та over у команді animate.
+var jsonKeywords = map[string]bool{"to": true, "by": true, "over": true}
+
+// jsonCommand перетворює об'єкт JSON на команду, аргументи якої мають той самий текстовий вигляд, що й у скрипті.
+func jsonCommand(obj map[string]json.RawMessage, n int) (command, *SyntaxError) {
+	errorf := func(tok, format string, args ...any) *SyntaxError {
+		return &SyntaxError{Line: n, Token: tok, Message: fmt.Sprintf(format, args...)}
+	}
+
+	var op string
+	if err := json.Unmarshal(obj["op"], &op); err != nil || op == "" {
+		return command{}, errorf("", `missing or invalid "op" field`)
+	}
+
+	cmd := command{name: token{text: op, line: n}}
+	names, known := jsonFields[op]
+	if !known {
+		// Невідому команду відхилить Parser з переліком доступних команд.
+		return cmd, nil
+	}
+
+	if raw, ok := obj["args"]; ok {
+		var args []json.RawMessage
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return command{}, errorf("args", `"args" must be an array`)
+		}
+		for _, arg := range args {
+			text, err := jsonArg(arg)
+			if err != nil {
+				return command{}, errorf(string(arg), "%s", err)
+			}
+			cmd.args = append(cmd.args, token{text: text, line: n})
+		}
+		return cmd, nil
+	}
+
+	allowed := map[string]bool{"op": true}
+	for _, name := range names {
+		allowed[name] = true
+		raw, ok := obj[name]
+		if !ok {
+			continue
+		}
+		texts, err := jsonArgs(raw)
+		if err != nil {
+			return command{}, errorf(name, "field %q: %s", name, err)
+		}
+		if jsonKeywords[name] {
+			cmd.args = append(cmd.args, token{text: name, line: n})
+		}
+		for _, text := range texts {
+			// Текст напису поводиться як слово в лапках: він не може бути ні ідентифікатором, ні параметром.
+			cmd.args = append(cmd.args, token{text: text, line: n, quoted: name == "text"})
+		}
+	}
+	for _, name := range shapeOptions[op] {
+		allowed[name] = true
+		raw, ok := obj[name]
+		if !ok {
+			continue
+		}
+		text, err := jsonArg(raw)
+		if err != nil {
+			return command{}, errorf(name, "field %q: %s", name, err)
+		}
+		cmd.args = append(cmd.args, token{text: name + "=" + text, line: n})
+	}
+	for name := range obj {
+		if !allowed[name] {
+			return command{}, errorf(name, "unknown field %q for %s", name, op)
+		}
+	}
+	return cmd, nil
+}
+
+// jsonArgs повертає текст аргументу або, якщо це масив, тексти всіх його елементів, включно з вкладеними масивами.
+func jsonArgs(raw json.RawMessage) ([]string, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		text, err := jsonArg(raw)
+		return []string{text}, err
+	}
+
+	var res []string
+	for _, item := range items {
+		texts, err := jsonArgs(item)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, texts...)
+	}
+	return res, nil
+}
+
+func jsonArg(raw json.RawMessage) (string, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("expected a string or a number, got %s", raw)
+}
@@ -0,0 +1,67 @@
+package lang_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func TestJSONParser(t *testing.T) {
+	input := `[
+		{"op": "bg", "color": "#ffffff"},
+		{"op": "figure", "id": "a", "x": 0.5, "y": 0.25, "color": "red"},
+		{"op": "figure", "args": [0.1, 0.1]},
+		{"op": "move", "id": "a", "dx": 0.1, "dy": 0},
+		{"op": "update"}
+	]`
+
+	state := lang.UpdateState()
+	parser := &lang.JSONParser{Parser: &lang.Parser{}}
+
+	ops, err := parser.Parse(strings.NewReader(input), state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if state.Figures.Len() != 2 {
+		t.Errorf("expected 2 figures, got %d", state.Figures.Len())
+	}
+	a, ok := figureByID(state, "a")
+	if !ok || a.X != 240 || a.Y != 100 || a.Color != lang.DefaultPalette["red"] {
+		t.Errorf("unexpected figure a: %+v", a)
+	}
+	if len(ops) == 0 || ops[len(ops)-1] != painter.UpdateOp {
+		t.Error("expected script to end with update operation")
+	}
+}
+
+func TestJSONParser_Errors(t *testing.T) {
+	input := `[
+		{"op": "figure", "x": 0.5},
+		{"op": "figure", "x": 0.5, "y": 0.5, "z": 1},
+		{"x": 1},
+		{"op": "unknown"}
+	]`
+
+	_, err := (&lang.JSONParser{}).Parse(strings.NewReader(input), lang.UpdateState())
+
+	var errs lang.SyntaxErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected SyntaxErrors, got %v", err)
+	}
+	if len(errs) != 4 {
+		t.Fatalf("expected 4 errors, got %d: %v", len(errs), errs)
+	}
+	for i, e := range errs {
+		if e.Line != i+1 {
+			t.Errorf("error %d reported for command %d", i, e.Line)
+		}
+	}
+
+	if _, err := (&lang.JSONParser{}).Parse(strings.NewReader(`{"op":`), lang.UpdateState()); err == nil {
+		t.Error("expected error for malformed JSON")
+	}
+}
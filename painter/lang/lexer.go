@@ -0,0 +1,129 @@
+package lang
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// scanCommands ділить скрипт на команди. Команда займає один рядок, а якщо рядок закінчується
+// зворотною косою рискою, то продовжується на наступному. Коментар починається з # на початку команди
+// або зі слова, у якому за # іде пробіл, ще одна # чи кінець рядка, тож кольори #rrggbb коментарями не є.
+// Лексична помилка пропускає лише команду, у якій трапилась, разом з рядками, на які команда продовжується.
+func scanCommands(in io.Reader) ([]command, SyntaxErrors, error) {
+	scanner := bufio.NewScanner(in)
+	scanner.Split(bufio.ScanLines)
+
+	var (
+		cmds   []command
+		errs   SyntaxErrors
+		cur    []token // токени команди, що продовжується з попередніх рядків
+		end    token
+		broken bool // команда з лексичною помилкою продовжується на наступному рядку
+	)
+	flush := func() {
+		if len(cur) > 0 {
+			cmds = append(cmds, command{name: cur[0], args: cur[1:], end: end})
+		}
+		cur = nil
+	}
+
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		toks, endCol, cont, err := lexLine(text, line, len(cur) == 0 && !broken)
+		if err != nil || broken {
+			if err != nil && !broken {
+				errs = append(errs, err)
+			}
+			if err != nil {
+				// Незакрита лапка поглинає решту рядка, тож продовження видно лише з його кінця.
+				cont = strings.HasSuffix(strings.TrimRightFunc(text, unicode.IsSpace), `\`)
+			}
+			cur, broken = nil, cont
+			continue
+		}
+		if len(toks) > 0 {
+			cur = append(cur, toks...)
+			end = token{line: line, col: endCol}
+		}
+		if !cont {
+			flush()
+		}
+	}
+	flush()
+	return cmds, errs, scanner.Err()
+}
+
+// lexLine ділить рядок на слова, розділені пробілами, запам'ятовуючи колонку початку кожного.
+// Слово у подвійних лапках може містити пробіли, а всередині лапок \" та \\ означають лапку та зворотну косу риску.
+// start означає, що рядок починає нову команду. endCol — колонка одразу після останнього слова,
+// cont — рядок закінчується зворотною косою рискою поза лапками, тож команда продовжується.
+func lexLine(line string, lineNum int, start bool) (toks []token, endCol int, cont bool, err *SyntaxError) {
+	runes := []rune(line)
+	var ends []int
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		if runes[i] == '#' && (start && len(toks) == 0 || comment(runes[i+1:])) {
+			break
+		}
+
+		tok := token{line: lineNum, col: i + 1}
+		if runes[i] != '"' {
+			begin := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) {
+				i++
+			}
+			tok.text = string(runes[begin:i])
+			toks, ends = append(toks, tok), append(ends, i+1)
+			continue
+		}
+
+		var sb strings.Builder
+		closed := false
+		for i++; i < len(runes); i++ {
+			r := runes[i]
+			if r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+				i++
+				sb.WriteRune(runes[i])
+				continue
+			}
+			if r == '"' {
+				closed = true
+				i++
+				break
+			}
+			sb.WriteRune(r)
+		}
+		if !closed {
+			return nil, 0, false, &SyntaxError{
+				Line: lineNum, Column: tok.col, Token: string(runes[tok.col-1:]),
+				Message: "unterminated quoted string",
+			}
+		}
+		tok.text, tok.quoted = sb.String(), true
+		toks, ends = append(toks, tok), append(ends, i+1)
+	}
+
+	if n := len(toks); n > 0 && !toks[n-1].quoted && strings.HasSuffix(toks[n-1].text, `\`) {
+		cont = true
+		if toks[n-1].text = strings.TrimSuffix(toks[n-1].text, `\`); toks[n-1].text == "" {
+			toks, ends = toks[:n-1], ends[:n-1]
+		} else {
+			ends[n-1]--
+		}
+	}
+	if len(ends) > 0 {
+		endCol = ends[len(ends)-1]
+	}
+	return toks, endCol, cont, nil
+}
+
+// comment перевіряє, чи є # перед rest початком коментаря: за нею має йти пробіл, ще одна # або кінець рядка.
+func comment(rest []rune) bool {
+	return len(rest) == 0 || rest[0] == '#' || unicode.IsSpace(rest[0])
+}
@@ -0,0 +1,108 @@
+package lang_test
+
+import (
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func TestParseComments(t *testing.T) {
+	input := `# scene header
+   ## indented comment
+#not-a-color at the start of a line
+bg #102030 # trailing comment
+circle c 0.5 0.5 0.1 fill=#ff0000 ##
+text 0.1 0.1 "# not a comment" #
+update #`
+
+	state := lang.UpdateState()
+	if _, err := (&lang.Parser{}).Parse(strings.NewReader(input), state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Figures.Len() != 2 {
+		t.Fatalf("expected 2 figures, got %v", state.Figures.IDs())
+	}
+	shape, _ := state.Figures.Get("c")
+	if c, ok := shape.(*painter.Circle); !ok || c.Fill == nil || color.RGBAModel.Convert(c.Fill) != (color.RGBA{R: 0xff, A: 0xff}) {
+		t.Errorf("expected #ff0000 to stay a color, got %+v", shape)
+	}
+	text, _ := state.Figures.Get(state.Figures.IDs()[1])
+	if txt, ok := text.(*painter.Text); !ok || txt.Text != "# not a comment" {
+		t.Errorf("expected # inside quotes to stay in the text, got %+v", text)
+	}
+}
+
+func TestParseLineContinuation(t *testing.T) {
+	// Порожній рядок після зворотної косої риски завершує команду.
+	input := "circle c \\\n  0.5 0.5 \\   # comment after continuation\n  0.1 fill=red\\\n\nupdate"
+
+	state := lang.UpdateState()
+	if _, err := (&lang.Parser{}).Parse(strings.NewReader(input), state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	shape, ok := state.Figures.Get("c")
+	if c, isCircle := shape.(*painter.Circle); !ok || !isCircle || c.R != 40 || c.Fill == nil {
+		t.Errorf("expected circle spanning several lines, got %+v", shape)
+	}
+}
+
+func TestLexerErrorPositions(t *testing.T) {
+	input := `figure \
+  0.5 zero
+circle c 0.5 \
+  0.5   # missing radius
+text 0.1 \
+  0.1 "unterminated
+bogus`
+
+	_, err := (&lang.Parser{}).Parse(strings.NewReader(input), lang.UpdateState())
+
+	var errs lang.SyntaxErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected SyntaxErrors, got %v", err)
+	}
+	want := []lang.SyntaxError{
+		{Line: 2, Column: 7, Token: "zero"},
+		{Line: 4, Column: 6, Token: ""},
+		{Line: 6, Column: 7, Token: `"unterminated`},
+		{Line: 7, Column: 1, Token: "bogus"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %d: %v", len(want), len(errs), errs)
+	}
+	for i, w := range want {
+		e := errs[i]
+		if e.Line != w.Line || e.Column != w.Column || e.Token != w.Token {
+			t.Errorf("error %d at %d:%d %q, want %d:%d %q", i, e.Line, e.Column, e.Token, w.Line, w.Column, w.Token)
+		}
+	}
+}
+
+func TestLexerErrorInContinuedCommand(t *testing.T) {
+	input := `text 0.1 0.1 "unterminated \
+  align=center \
+  size=12
+figure 0.5 0.5`
+
+	state := lang.UpdateState()
+	_, err := (&lang.Parser{}).Parse(strings.NewReader(input), state)
+
+	var errs lang.SyntaxErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected SyntaxErrors, got %v", err)
+	}
+	if len(errs) != 1 || errs[0].Line != 1 || errs[0].Column != 14 {
+		t.Fatalf("expected only the unterminated quote at 1:14, got %v", errs)
+	}
+
+	// Команда після пропущених рядків розбирається як звичайно.
+	input = strings.Replace(input, "figure 0.5 0.5", "figure 0.5 zero", 1)
+	_, err = (&lang.Parser{}).Parse(strings.NewReader(input), state)
+	if !errors.As(err, &errs) || len(errs) != 2 || errs[1].Line != 4 || errs[1].Token != "zero" {
+		t.Errorf("expected the command after the broken one to be parsed, got %v", err)
+	}
+}
@@ -1,151 +1,508 @@
 package lang
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
+	"image"
 	"image/color"
 	"io"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/roman-mazur/architecture-lab-3/painter"
 )
 
+// Parser розбирає текстові скрипти з командами для painter.Loop.
 type Parser struct {
+	// Palette задає додаткові назви кольорів. Назви, яких тут немає, шукаються у DefaultPalette.
+	Palette Palette
+
+	// Size — розмір полотна, на який відображаються нормалізовані координати, поки скрипт не змінить його
+	// командою canvas. Має збігатися з painter.Loop.Size. Якщо не задано, використовується painter.DefaultCanvasSize.
+	Size image.Point
 }
 
-var isReset = false
+// Найбільший розмір полотна, який можна задати командою canvas.
+const maxCanvasSize = 8192
 
+// CurState — стан сцени, з якого будується список операцій для відображення.
+// Анімації змінюють стан у власних горутинах, тому, поки вони працюють, стан варто змінювати лише через Parse.
 type CurState struct {
-	Figures    []*painter.Figure
+	Figures    Figures
 	BgRectFill []*painter.BgRect
 
-	BgColorOp painter.OperationFunc
-	UpdateOp  painter.Operation
-	MoveOp    []painter.Operation
+	BgColor  color.Color
+	UpdateOp painter.Operation
+
+	// Selection — шаблон ідентифікаторів фігур, які переміщує move без явного ідентифікатора.
+	// Порожній рядок означає всі фігури.
+	Selection string
+
+	// Size — розмір полотна. Нульове значення означає розмір за замовчуванням Parser,
+	// після першої команди стан зберігає його явно.
+	Size image.Point
+
+	// History дозволяє скасовувати та повторювати зміни сцени.
+	History History
+
+	// live — постійний стан, у якому анімації переміщують фігури, бо Parser змінює лише копію стану.
+	// mu захищає його від одночасних змін, а hub розсилає події сцени. Стан Session використовує блокування
+	// сесії, а стан без сесії отримує власне блокування, яке бере Parse (ownLock).
+	live    *CurState
+	mu      sync.Locker
+	ownLock bool
+	hub     *eventHub
+
+	resets int // кількість виконаних команд reset, за якою Session помічає очищення сцени
 }
 
 func UpdateState() *CurState { return &CurState{} }
 
-func (p *Parser) Parse(in io.Reader, s *CurState) ([]painter.Operation, error) {
-	scanner := bufio.NewScanner(in)
-	scanner.Split(bufio.ScanLines)
+// addFigure додає фігуру з ідентифікатором id або з автоматичним ідентифікатором, якщо id не задано.
+func (s *CurState) addFigure(cmd command, id *token, fig painter.Shape) *SyntaxError {
+	if id == nil {
+		s.Figures.Add(fig)
+		return nil
+	}
+	if err := validFigureID(id.text); err != nil {
+		return cmd.errorAt(*id, err.Error())
+	}
+	s.Figures.Set(id.text, fig)
+	return nil
+}
 
-	var res []painter.Operation
+func (s *CurState) selection() string {
+	if s.Selection == "" {
+		return "*"
+	}
+	return s.Selection
+}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
+// clone повертає копію стану, зміни якої не впливають на оригінал.
+func (s *CurState) clone() *CurState {
+	res := *s
+	res.Figures = s.Figures.clone()
+	res.BgRectFill = append([]*painter.BgRect(nil), s.BgRectFill...)
+	res.History = s.History.clone()
+	return &res
+}
+
+// usage описує очікувану форму кожної команди для повідомлень про помилки.
+var usage = map[string]string{
+	"update": "update",
+	"white":  "white",
+	"green":  "green",
+	"bg":     "bg <color>",
+	"bgrect": "bgrect x1 y1 x2 y2 [color]",
+	"figure": "figure [id] x y [color]",
+	"canvas": "canvas <width> <height>",
+
+	"circle":   "circle [id] x y r [fill=<color>] [stroke=<color>] [width=<px>]",
+	"ellipse":  "ellipse [id] x y rx ry [fill=<color>] [stroke=<color>] [width=<px>]",
+	"line":     "line [id] x1 y1 x2 y2 [stroke=<color>] [width=<px>]",
+	"polyline": "polyline [id] x1 y1 x2 y2 ... [stroke=<color>] [width=<px>]",
+	"polygon":  "polygon [id] x1 y1 x2 y2 x3 y3 ... [fill=<color>] [stroke=<color>] [width=<px>]",
+	"text":     `text [id] x y "text" [size=<px>] [color=<color>] [align=left|center|right] [font=go|basic]`,
+	"move":     "move [id-pattern] dx dy",
+	"animate":  "animate <id> to|by x y over <duration> [linear|ease-in|ease-out|ease-in-out]",
+	"stop":     "stop <id-pattern>",
+	"delete":   "delete <id-pattern>",
+	"select":   "select <id-pattern>",
+	"reset":    "reset",
+	"undo":     "undo [n]",
+	"redo":     "redo [n]",
+}
+
+// undoable — команди, які змінюють сцену і тому записуються в історію.
+var undoable = map[string]bool{
+	"white": true, "green": true, "bg": true, "bgrect": true, "figure": true,
+	"circle": true, "ellipse": true, "line": true, "polyline": true, "polygon": true, "text": true,
+	"move": true, "delete": true, "canvas": true, "reset": true,
+}
+
+// token — слово скрипту та його позиція.
+type token struct {
+	text      string
+	line, col int
+	quoted    bool // слово було у лапках, тож не може бути ідентифікатором, числом чи параметром
+}
+
+// command — одна команда скрипту: назва та аргументи.
+type command struct {
+	name token
+	args []token
+	end  token // позиція одразу після останнього токена, на неї вказують помилки про відсутні аргументи
+}
+
+// Parse розбирає скрипт і застосовує його до стану s. Якщо у скрипті є помилки, стан не змінюється,
+// а повертається SyntaxErrors з усіма знайденими помилками. Скрипт може містити коментарі # та команди,
+// перенесені на наступний рядок зворотною косою рискою, див. scanCommands.
+func (p *Parser) Parse(in io.Reader, s *CurState) ([]painter.Operation, error) {
+	cmds, errs, err := scanCommands(in)
+	if err != nil {
+		return nil, err
+	}
+	return p.apply(cmds, s, errs)
+}
+
+// apply виконує команди над копією стану s і переносить результат у s, лише якщо помилок не було.
+// Помилки, знайдені раніше під час читання вхідних даних, передаються у errs і об'єднуються з рештою.
+func (p *Parser) apply(cmds []command, s *CurState, errs SyntaxErrors) ([]painter.Operation, error) {
+	if s.live == nil {
+		s.live = s
+	}
+	if s.mu == nil {
+		s.mu, s.ownLock = new(sync.Mutex), true
+	}
+	if s.ownLock {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	next := s.clone()
 
-		operations, err := parseLine(line, s)
+	var res []painter.Operation
+	for _, cmd := range cmds {
+		operations, err := p.exec(cmd, next)
 		if err != nil {
-			return nil, fmt.Errorf("[Error]: parse error on line '%s': %w", line, err)
+			errs = append(errs, err)
+			continue
 		}
-
 		res = append(res, operations...)
 	}
 
-	return res, scanner.Err()
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+		return nil, errs
+	}
+	*s = *next
+	return res, nil
 }
 
-func parseLine(line string, s *CurState) ([]painter.Operation, error) {
-	fields := strings.Fields(line)
-	const size = 400
+// canvasSize повертає розмір полотна, на який відображаються нормалізовані координати стану s.
+func (p *Parser) canvasSize(s *CurState) image.Point {
+	switch {
+	case s.Size != image.Point{}:
+		return s.Size
+	case p.Size != image.Point{}:
+		return p.Size
+	}
+	return painter.DefaultCanvasSize
+}
 
-	if len(fields) == 0 {
-		return nil, nil
+func (p *Parser) exec(cmd command, s *CurState) ([]painter.Operation, *SyntaxError) {
+	size := p.canvasSize(s)
+	s.Size = size
+
+	if undoable[cmd.name.text] {
+		// Знімок зберігається до перевірки аргументів; якщо команда некоректна, apply відкине весь стан.
+		s.History.record(s)
 	}
 
-	switch fields[0] {
+	switch cmd.name.text {
 	case "update":
+		if err := cmd.arity(0, 0); err != nil {
+			return nil, err
+		}
 		s.UpdateOp = painter.UpdateOp
 
-	case "white":
-		s.BgColorOp = painter.WhiteBackgroundOp(color.White)
+	case "white", "green":
+		// Старі команди є псевдонімами для bg white та bg green.
+		if err := cmd.arity(0, 0); err != nil {
+			return nil, err
+		}
+		c, err := ParseColor(cmd.name.text, p.Palette)
+		if err != nil {
+			return nil, cmd.errorAt(cmd.name, err.Error())
+		}
+		s.BgColor = c
 
-	case "green":
-		s.BgColorOp = painter.GreenBackgroundOp(color.RGBA{0, 255, 0, 255})
+	case "bg":
+		if err := cmd.arity(1, 1); err != nil {
+			return nil, err
+		}
+		c, err := p.color(cmd, cmd.args[0])
+		if err != nil {
+			return nil, err
+		}
+		s.BgColor = c
 
 	case "bgrect":
-		vals, err := parseFloatNum(fields, 4)
+		vals, c, err := p.floatsWithColor(cmd, cmd.args, 4)
 		if err != nil {
 			return nil, err
 		}
 
 		op := &painter.BgRect{
-			X1: int(vals[0] * size), Y1: int(vals[1] * size),
-			X2: int(vals[2] * size), Y2: int(vals[3] * size),
+			X1: int(vals[0] * float64(size.X)), Y1: int(vals[1] * float64(size.Y)),
+			X2: int(vals[2] * float64(size.X)), Y2: int(vals[3] * float64(size.Y)),
+			Color: c,
 		}
 
 		s.BgRectFill = append(s.BgRectFill, op)
 
 	case "figure":
-		vals, err := parseFloatNum(fields, 2)
+		// figure [id] x y [color]
+		id, args := cmd.optionalID()
+		vals, c, err := p.floatsWithColor(cmd, args, 2)
+		if err != nil {
+			return nil, err
+		}
+		fig := &painter.Figure{
+			X: int(vals[0] * float64(size.X)), Y: int(vals[1] * float64(size.Y)),
+			Color: c,
+		}
+		if err := s.addFigure(cmd, id, fig); err != nil {
+			return nil, err
+		}
 
+	case "circle", "ellipse", "line", "polyline", "polygon", "text":
+		// circle [id] x y r [options], ellipse [id] x y rx ry [options], line [id] x1 y1 x2 y2 [options],
+		// polyline та polygon [id] x1 y1 x2 y2 ... [options], text [id] x y "текст" [options]
+		id, args := cmd.optionalID()
+		shape, err := p.parseShape(cmd, args, size)
 		if err != nil {
 			return nil, err
 		}
-		s.Figures = append(s.Figures, &painter.Figure{
-			X: int(vals[0] * size), Y: int(vals[1] * size),
-		})
+		if err := s.addFigure(cmd, id, shape); err != nil {
+			return nil, err
+		}
 
 	case "move":
-		vals, err := parseFloatNum(fields, 2)
+		// move [id-pattern] dx dy
+		id, args := cmd.optionalID()
+		skipped := len(cmd.args) - len(args)
+		if err := cmd.arity(2+skipped, 2+skipped); err != nil {
+			return nil, err
+		}
+		vals, err := cmd.floats(args)
+		if err != nil {
+			return nil, err
+		}
 
+		pattern := s.selection()
+		if id != nil {
+			pattern = id.text
+		}
+		ids, matchErr := s.Figures.Match(pattern)
+		if matchErr != nil {
+			if id == nil {
+				return nil, cmd.errorAt(cmd.name, matchErr.Error())
+			}
+			return nil, cmd.errorAt(*id, matchErr.Error())
+		}
+
+		dx, dy := int(vals[0]*float64(size.X)), int(vals[1]*float64(size.Y))
+		for _, id := range ids {
+			fig, _ := s.Figures.Get(id)
+			s.Figures.Set(id, fig.Moved(dx, dy))
+		}
+
+	case "animate":
+		op, err := p.parseAnimate(cmd, s, size)
 		if err != nil {
 			return nil, err
 		}
-		dx, dy := int(vals[0]*400), int(vals[1]*400)
+		return []painter.Operation{op}, nil
+
+	case "stop":
+		if err := cmd.arity(1, 1); err != nil {
+			return nil, err
+		}
+		if _, err := s.Figures.Match(cmd.args[0].text); err != nil {
+			return nil, cmd.errorAt(cmd.args[0], err.Error())
+		}
+		return []painter.Operation{painter.StopAnimationOp{ID: cmd.args[0].text}}, nil
+
+	case "delete":
+		if err := cmd.arity(1, 1); err != nil {
+			return nil, err
+		}
+		ids, err := s.Figures.Match(cmd.args[0].text)
+		if err != nil {
+			return nil, cmd.errorAt(cmd.args[0], err.Error())
+		}
+		for _, id := range ids {
+			s.Figures.Delete(id)
+		}
 
-		moveOp := painter.MoveOp{Mx: dx, My: dy, Figures: s.Figures}
+	case "select":
+		if err := cmd.arity(1, 1); err != nil {
+			return nil, err
+		}
+		if _, err := s.Figures.Match(cmd.args[0].text); err != nil {
+			return nil, cmd.errorAt(cmd.args[0], err.Error())
+		}
+		s.Selection = cmd.args[0].text
+		return nil, nil
 
-		return []painter.Operation{moveOp}, nil
+	case "canvas":
+		if err := cmd.arity(2, 2); err != nil {
+			return nil, err
+		}
+		var dims [2]int
+		for i, arg := range cmd.args {
+			v, err := strconv.Atoi(arg.text)
+			if err != nil || v <= 0 || v > maxCanvasSize {
+				return nil, cmd.errorAt(arg, fmt.Sprintf("canvas size must be an integer from 1 to %d", maxCanvasSize))
+			}
+			dims[i] = v
+		}
+		s.Size = image.Pt(dims[0], dims[1])
+		return append([]painter.Operation{painter.CanvasOp{Size: s.Size}}, buildOps(s)...), nil
 
 	case "reset":
+		if err := cmd.arity(0, 0); err != nil {
+			return nil, err
+		}
+		// Розмір полотна та історія зберігаються, бо Loop не змінює текстури під час reset.
+		// Анімації зупиняються, бо їхніх фігур більше немає.
+		prev := *s
 		*s = *UpdateState()
-		isReset = true
-		return []painter.Operation{painter.Reset()}, nil
+		s.Size, s.History, s.live, s.hub = prev.Size, prev.History, prev.live, prev.hub
+		s.mu, s.ownLock = prev.mu, prev.ownLock
+		s.resets = prev.resets + 1
+		return []painter.Operation{painter.StopAnimationOp{ID: "*"}, painter.Reset()}, nil
+
+	case "undo", "redo":
+		if err := cmd.arity(0, 1); err != nil {
+			return nil, err
+		}
+		n := 1
+		if len(cmd.args) == 1 {
+			v, err := strconv.Atoi(cmd.args[0].text)
+			if err != nil || v <= 0 {
+				return nil, cmd.errorAt(cmd.args[0], "step count must be a positive integer")
+			}
+			n = v
+		}
+		travel := undo
+		if cmd.name.text == "redo" {
+			travel = redo
+		}
+		ops, err := travel(s, n)
+		if err != nil {
+			return nil, cmd.errorAt(cmd.name, err.Error())
+		}
+		return ops, nil
 
 	default:
-		return nil, fmt.Errorf("[Error]: unknown command: %s", fields[0])
+		err := cmd.errorAt(cmd.name, "unknown command: "+cmd.name.text)
+		err.Expected = "one of " + strings.Join(commandNames(), ", ")
+		return nil, err
 	}
 
 	return buildOps(s), nil
 }
 
-func parseFloatNum(fields []string, count int) ([]float64, error) {
-	if len(fields) != count+1 {
-		return nil, fmt.Errorf("[Error]: expected %d args, got %d", count, len(fields)-1)
+func commandNames() []string {
+	names := make([]string, 0, len(usage))
+	for name := range usage {
+		names = append(names, name)
 	}
-	values := make([]float64, count)
-	for i := 0; i < count; i++ {
-		v, err := strconv.ParseFloat(fields[i+1], 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid numeric value: %s", fields[i+1])
+	sort.Strings(names)
+	return names
+}
+
+// errorAt створює помилку, що вказує на токен tok.
+func (cmd command) errorAt(tok token, msg string) *SyntaxError {
+	line := tok.line
+	if line == 0 {
+		line = cmd.name.line
+	}
+	return &SyntaxError{
+		Line:     line,
+		Column:   tok.col,
+		Token:    tok.text,
+		Expected: usage[cmd.name.text],
+		Message:  msg,
+	}
+}
+
+// arity перевіряє, що команда має від min до max аргументів.
+func (cmd command) arity(min, max int) *SyntaxError {
+	n := len(cmd.args)
+	switch {
+	case n < min:
+		return cmd.errorAt(cmd.end, fmt.Sprintf("expected %d args, got %d", min, n))
+	case n > max:
+		return cmd.errorAt(cmd.args[max], fmt.Sprintf("expected %d args, got %d", max, n))
+	}
+	return nil
+}
+
+// optionalID відокремлює необов'язковий ідентифікатор, що йде першим аргументом команди.
+// Аргумент вважається ідентифікатором, якщо він не є числом, зокрема числом поза межами float64.
+func (cmd command) optionalID() (*token, []token) {
+	if len(cmd.args) == 0 {
+		return nil, cmd.args
+	}
+	if _, err := strconv.ParseFloat(cmd.args[0].text, 64); err == nil || errors.Is(err, strconv.ErrRange) || cmd.args[0].quoted {
+		return nil, cmd.args
+	}
+	return &cmd.args[0], cmd.args[1:]
+}
+
+// floats розбирає числові аргументи. NaN та нескінченності відхиляються, бо не задають жодної позиції.
+func (cmd command) floats(args []token) ([]float64, *SyntaxError) {
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		v, err := strconv.ParseFloat(arg.text, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, cmd.errorAt(arg, "invalid numeric value: "+arg.text)
 		}
 		values[i] = v
 	}
 	return values, nil
 }
 
+func (p *Parser) color(cmd command, arg token) (color.Color, *SyntaxError) {
+	c, err := ParseColor(arg.text, p.Palette)
+	if err != nil {
+		return nil, cmd.errorAt(arg, err.Error())
+	}
+	return c, nil
+}
+
+// floatsWithColor розбирає count числових аргументів, після яких може йти необов'язковий колір.
+func (p *Parser) floatsWithColor(cmd command, args []token, count int) ([]float64, color.Color, *SyntaxError) {
+	skipped := len(cmd.args) - len(args) // аргументи перед числами, наприклад ідентифікатор
+	if err := cmd.arity(count+skipped, count+1+skipped); err != nil {
+		return nil, nil, err
+	}
+
+	vals, err := cmd.floats(args[:count])
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(args) == count {
+		return vals, nil, nil
+	}
+	c, err := p.color(cmd, args[count])
+	if err != nil {
+		return nil, nil, err
+	}
+	return vals, c, nil
+}
+
 func buildOps(s *CurState) []painter.Operation {
 	var ops []painter.Operation
 
-	if s.BgColorOp != nil {
-		ops = append(ops, s.BgColorOp)
-	} else {
+	if s.BgColor == nil {
 		// For move command without figure bg is green
-		s.BgColorOp = painter.GreenBackgroundOp(color.RGBA{0, 255, 0, 255})
-		ops = append(ops, s.BgColorOp)
+		s.BgColor = DefaultPalette["green"]
 	}
+	ops = append(ops, painter.BackgroundOp(s.BgColor))
 
 	if len(s.BgRectFill) > 0 {
 		// draw last rectangle
 		ops = append(ops, s.BgRectFill[len(s.BgRectFill)-1])
 	}
-	for _, fig := range s.Figures {
+	for _, id := range s.Figures.IDs() {
+		// Фігури незмінні, тож Loop може малювати їх, поки наступні команди змінюють стан.
+		fig, _ := s.Figures.Get(id)
 		ops = append(ops, fig)
 	}
 	if s.UpdateOp != nil {
@@ -1,6 +1,10 @@
 package lang_test
 
 import (
+	"errors"
+	"image"
+	"image/color"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -8,6 +12,13 @@ import (
 	"github.com/roman-mazur/architecture-lab-3/painter/lang"
 )
 
+// figureByID повертає хрест з ідентифікатором id зі стану сцени.
+func figureByID(state *lang.CurState, id string) (*painter.Figure, bool) {
+	shape, ok := state.Figures.Get(id)
+	fig, isFigure := shape.(*painter.Figure)
+	return fig, ok && isFigure
+}
+
 func TestParseFigureAndUpdate(t *testing.T) {
 	input := `figure 0.1 0.2
 update`
@@ -73,12 +84,50 @@ update`
 	}
 }
 
-func TestParseMove(t *testing.T) {
+func TestParseColors(t *testing.T) {
+	input := `bg #102030
+bgrect 0 0 0.5 0.5 rgb(1,2,3)
+figure 0.5 0.5 red
+update`
+
 	state := lang.UpdateState()
+	parser := &lang.Parser{}
+
+	ops, err := parser.Parse(strings.NewReader(input), state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if state.BgColor != (color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xff}) {
+		t.Errorf("unexpected background color %v", state.BgColor)
+	}
+
+	var rect *painter.BgRect
+	var fig *painter.Figure
+	for _, op := range ops {
+		switch op := op.(type) {
+		case *painter.BgRect:
+			rect = op
+		case *painter.Figure:
+			fig = op
+		}
+	}
+
+	if rect == nil || rect.Color != (color.NRGBA{R: 1, G: 2, B: 3, A: 255}) {
+		t.Errorf("expected rectangle with rgb(1,2,3) color, got %+v", rect)
+	}
+	if fig == nil || fig.Color != lang.DefaultPalette["red"] {
+		t.Errorf("expected red figure, got %+v", fig)
+	}
 
-	state.Figures = []*painter.Figure{
-		{X: 100, Y: 100},
+	if _, err := parser.Parse(strings.NewReader("figure 0.5 0.5 nocolor"), state); err == nil {
+		t.Error("expected error for unknown color")
 	}
+}
+
+func TestParseMove(t *testing.T) {
+	state := lang.UpdateState()
+	state.Figures.Set("a", &painter.Figure{X: 100, Y: 100})
 
 	input := `move 0.1 0.2`
 
@@ -88,22 +137,81 @@ func TestParseMove(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if len(ops) != 1 {
-		t.Fatalf("expected 1 move operation, got %d", len(ops))
-	}
-
-	ok := ops[0].Do(nil)
-	if !ok {
-		t.Errorf("expected Do to return true")
-	}
-
-	fig := state.Figures[0]
+	fig, _ := figureByID(state, "a")
 	expectedX := 100 + int(0.1*400)
 	expectedY := 100 + int(0.2*400)
 
 	if fig.X != expectedX || fig.Y != expectedY {
 		t.Errorf("expected figure at (%d,%d), got (%d,%d)", expectedX, expectedY, fig.X, fig.Y)
 	}
+
+	var drawn *painter.Figure
+	for _, op := range ops {
+		if f, ok := op.(*painter.Figure); ok {
+			drawn = f
+		}
+	}
+	if drawn == nil || drawn.X != expectedX || drawn.Y != expectedY {
+		t.Errorf("expected moved figure to be redrawn at (%d,%d), got %+v", expectedX, expectedY, drawn)
+	}
+}
+
+func TestParseNamedFigures(t *testing.T) {
+	input := `figure a 0.1 0.1
+figure b 0.2 0.2
+figure c 0.3 0.3
+move b 0.1 0
+move * 0 0.1
+delete c
+figure a 0.5 0.5`
+
+	state := lang.UpdateState()
+	parser := &lang.Parser{}
+
+	if _, err := parser.Parse(strings.NewReader(input), state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ids := state.Figures.IDs(); !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Fatalf("unexpected figures %v", ids)
+	}
+
+	a, _ := figureByID(state, "a")
+	if a.X != 200 || a.Y != 200 {
+		t.Errorf("figure a expected at (200,200), got (%d,%d)", a.X, a.Y)
+	}
+	b, _ := figureByID(state, "b")
+	if b.X != 120 || b.Y != 120 {
+		t.Errorf("figure b expected at (120,120), got (%d,%d)", b.X, b.Y)
+	}
+
+	for _, bad := range []string{"move x 0.1 0.1", "delete x", "figure 1 0.1 0.1 0.1", "figure a* 0.1 0.1"} {
+		if _, err := parser.Parse(strings.NewReader(bad), state); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestParseSelect(t *testing.T) {
+	input := `figure left1 0.1 0.1
+figure left2 0.1 0.2
+figure right 0.9 0.1
+select left*
+move 0.1 0`
+
+	state := lang.UpdateState()
+	parser := &lang.Parser{}
+
+	if _, err := parser.Parse(strings.NewReader(input), state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for id, wantX := range map[string]int{"left1": 80, "left2": 80, "right": 360} {
+		fig, _ := figureByID(state, id)
+		if fig.X != wantX {
+			t.Errorf("figure %s expected at x=%d, got %d", id, wantX, fig.X)
+		}
+	}
 }
 
 func TestParseReset(t *testing.T) {
@@ -126,7 +234,7 @@ reset`
 		t.Error("expected first operation to be ResetOp")
 	}
 
-	if len(state.Figures) != 0 {
+	if state.Figures.Len() != 0 {
 		t.Error("expected state to be reset (no figures), but figures still present")
 	}
 }
@@ -142,3 +250,113 @@ func TestErrorCommand(t *testing.T) {
 		t.Fatal("expected error for unknown command")
 	}
 }
+
+func TestSyntaxErrors(t *testing.T) {
+	input := `figure a 0.1 0.1
+figure 0.5 zero
+bogus 1 2
+  move a 0.1
+bg`
+
+	state := lang.UpdateState()
+	parser := &lang.Parser{}
+
+	_, err := parser.Parse(strings.NewReader(input), state)
+
+	var errs lang.SyntaxErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected SyntaxErrors, got %v", err)
+	}
+
+	want := []lang.SyntaxError{
+		{Line: 2, Column: 12, Token: "zero"},
+		{Line: 3, Column: 1, Token: "bogus"},
+		{Line: 4, Column: 13, Token: ""},
+		{Line: 5, Column: 3, Token: ""},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %d: %v", len(want), len(errs), errs)
+	}
+	for i, w := range want {
+		e := errs[i]
+		if e.Line != w.Line || e.Column != w.Column || e.Token != w.Token {
+			t.Errorf("error %d at %d:%d %q, want %d:%d %q", i, e.Line, e.Column, e.Token, w.Line, w.Column, w.Token)
+		}
+		if e.Expected == "" {
+			t.Errorf("error %d has no expected form", i)
+		}
+	}
+
+	if state.Figures.Len() != 0 {
+		t.Error("state must not change when script has errors")
+	}
+}
+
+func TestSyntaxErrors_NotFinite(t *testing.T) {
+	input := `figure NaN 0.5
+move 0.1 +Inf
+bgrect 0 0 -inf 1
+figure 1e400 0.5`
+
+	state := lang.UpdateState()
+	_, err := (&lang.Parser{}).Parse(strings.NewReader(input), state)
+
+	var errs lang.SyntaxErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected SyntaxErrors, got %v", err)
+	}
+	want := []string{"NaN", "+Inf", "-inf", "1e400"}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %d: %v", len(want), len(errs), errs)
+	}
+	for i, w := range want {
+		if errs[i].Line != i+1 || errs[i].Token != w {
+			t.Errorf("error %d at line %d on %q, want line %d on %q", i, errs[i].Line, errs[i].Token, i+1, w)
+		}
+	}
+	if state.Figures.Len() != 0 {
+		t.Error("state must not change when script has errors")
+	}
+}
+
+func TestParseCanvas(t *testing.T) {
+	state := lang.UpdateState()
+	parser := &lang.Parser{Size: image.Pt(1600, 900)}
+
+	ops, err := parser.Parse(strings.NewReader("figure a 0.5 0.5\ncanvas 800 200\nfigure b 0.5 0.5"), state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	a, _ := figureByID(state, "a")
+	if a.X != 800 || a.Y != 450 {
+		t.Errorf("figure a expected at (800,450), got (%d,%d)", a.X, a.Y)
+	}
+	b, _ := figureByID(state, "b")
+	if b.X != 400 || b.Y != 100 {
+		t.Errorf("figure b expected at (400,100), got (%d,%d)", b.X, b.Y)
+	}
+
+	found := false
+	for _, op := range ops {
+		if c, ok := op.(painter.CanvasOp); ok && c.Size == image.Pt(800, 200) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected canvas operation")
+	}
+
+	if _, err := parser.Parse(strings.NewReader("reset"), state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Size != image.Pt(800, 200) {
+		t.Errorf("canvas size must survive reset, got %v", state.Size)
+	}
+
+	for _, bad := range []string{"canvas 0 10", "canvas 10", "canvas 1.5 10"} {
+		if _, err := parser.Parse(strings.NewReader(bad), state); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
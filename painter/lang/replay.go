@@ -0,0 +1,86 @@
+package lang
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// LogEntry — запис журналу сесії: прийнятий скрипт та звідки і коли він надійшов.
+type LogEntry struct {
+	Time        time.Time `json:"time"`
+	Remote      string    `json:"remote,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Script      string    `json:"script"`
+}
+
+// ScriptLog дописує записи журналу сесії у w у форматі JSON Lines. Безпечний для використання з кількох горутин.
+type ScriptLog struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewScriptLog створює журнал сесії, який пише у w.
+func NewScriptLog(w io.Writer) *ScriptLog {
+	return &ScriptLog{enc: json.NewEncoder(w)}
+}
+
+// Record дописує запис у журнал.
+func (l *ScriptLog) Record(e LogEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enc.Encode(e)
+}
+
+// ReadLog читає журнал сесії, записаний ScriptLog.
+func ReadLog(r io.Reader) ([]LogEntry, error) {
+	var entries []LogEntry
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 16<<20)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var e LogEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// Replay застосовує записи журналу до сесії обробника і передає отримані операції у його Loop, зберігаючи
+// інтервали між записами, поділені на speed. Якщо speed <= 0, записи застосовуються без затримок.
+// Кожен запис чекає на показ свого кадру, тож жодна операція не губиться через заповнену чергу.
+func (h *Handler) Replay(ctx context.Context, entries []LogEntry, speed float64) error {
+	start := time.Now()
+	for i, e := range entries {
+		if speed > 0 {
+			at := start.Add(time.Duration(float64(e.Time.Sub(entries[0].Time)) / speed))
+			timer := time.NewTimer(time.Until(at))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		ops, err := h.Session.Parse(h.decoder(e.ContentType), strings.NewReader(e.Script))
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		if err := h.Loop.PostAndWait(ctx, painter.OperationList(ops)); err != nil {
+			return fmt.Errorf("entry %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
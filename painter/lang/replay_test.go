@@ -0,0 +1,109 @@
+package lang_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func TestReplay(t *testing.T) {
+	loop, rec := startLoop(t)
+	var buf bytes.Buffer
+	session := lang.NewSession()
+	session.Log = lang.NewScriptLog(&buf)
+	handler := lang.HttpHandler(loop, &lang.Parser{}, session)
+	undo := lang.UndoHandler(loop, session)
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/", strings.NewReader("white\nfigure 0.5 0.5\nupdate")),
+		httptest.NewRequest(http.MethodPost, "/", strings.NewReader("unknown")),
+		httptest.NewRequest(http.MethodGet, "/?cmd=green%0Aupdate", nil),
+		httptest.NewRequest(http.MethodPost, "/undo?wait=true", nil),
+	}
+	requests[0].Header.Set("Content-Type", "text/plain")
+	for i, req := range requests {
+		h := http.Handler(handler)
+		if i == 3 {
+			h = undo
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	entries, err := lang.ReadLog(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 accepted scripts in the log, got %+v", entries)
+	}
+	if e := entries[0]; e.ContentType != "text/plain" || e.Remote != requests[0].RemoteAddr || e.Time.IsZero() {
+		t.Errorf("unexpected first entry %+v", e)
+	}
+	if s := entries[1].Script; s != "green\nupdate" {
+		t.Errorf("expected GET command in the log, got %q", s)
+	}
+	if s := entries[2].Script; s != "undo 1" {
+		t.Errorf("expected undo command in the log, got %q", s)
+	}
+
+	replayLoop, replayRec := startLoop(t)
+	replay := lang.HttpHandler(replayLoop, &lang.Parser{}, lang.NewSession())
+	if err := replay.Replay(context.Background(), entries, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(replayRec.Frame().Pix, rec.Frame().Pix) {
+		t.Error("replayed frame differs from the recorded session")
+	}
+}
+
+func TestReplay_RejectedNotLogged(t *testing.T) {
+	loop, _, _ := blockedLoop(t)
+	var buf bytes.Buffer
+	session := lang.NewSession()
+	session.Log = lang.NewScriptLog(&buf)
+	handler := lang.HttpHandler(loop, &lang.Parser{}, session)
+
+	codes := make([]int, 2)
+	for i := range codes {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?cmd=update", nil))
+		codes[i] = resp.Code
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Fatalf("expected 200 and 429, got %v", codes)
+	}
+	if entries, err := lang.ReadLog(&buf); err != nil || len(entries) != 1 {
+		t.Errorf("expected only the accepted script in the log, got %+v, %v", entries, err)
+	}
+}
+
+func TestReplay_Speed(t *testing.T) {
+	loop, _ := startLoop(t)
+	handler := lang.HttpHandler(loop, &lang.Parser{}, lang.NewSession())
+
+	now := time.Now()
+	entries := []lang.LogEntry{
+		{Time: now, Script: "white"},
+		{Time: now.Add(time.Second), Script: "update"},
+	}
+
+	start := time.Now()
+	if err := handler.Replay(context.Background(), entries, 10); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 100*time.Millisecond || d > 900*time.Millisecond {
+		t.Errorf("expected about 100ms at 10x speed, took %s", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := handler.Replay(ctx, entries, 1); err == nil {
+		t.Error("expected cancelled replay to fail")
+	}
+}
@@ -0,0 +1,99 @@
+package lang
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// Session зберігає стан сцени між запитами: фігури, прямокутники та фон накопичуються,
+// доки не надійде команда reset.
+type Session struct {
+	// Log, якщо задано, отримує кожен скрипт, операції якого прийняв Loop обробників HTTP, зокрема команди undo та redo.
+	// Задається до початку обслуговування запитів.
+	Log *ScriptLog
+
+	mu    sync.Mutex
+	state *CurState
+	hub   eventHub
+}
+
+// NewSession створює сесію з порожнім станом сцени.
+func NewSession() *Session {
+	s := &Session{state: UpdateState()}
+	s.state.live, s.state.mu, s.state.hub = s.state, &s.mu, &s.hub
+	return s
+}
+
+// Subscribe повертає канал подій сцени: додавання, переміщення, зміни та видалення фігур і reset.
+// Події для підписника, який не встигає їх читати, відкидаються. cancel відписує та закриває канал.
+func (s *Session) Subscribe() (events <-chan SceneEvent, cancel func()) {
+	return s.hub.subscribe()
+}
+
+// Parse розбирає вхідні дані декодером d у контексті стану сесії та повертає операції для painter.Loop.
+func (s *Session) Parse(d Decoder, in io.Reader) ([]painter.Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.notify()()
+	return d.Parse(in, s.state)
+}
+
+// commit виконує f над копією стану сесії та передає отримані операції у post. Стан сесії замінюється копією,
+// а скрипт запиту r записується у Log, лише якщо post успішна, тож запит, який Loop не прийняв, можна повторити.
+// Записи потрапляють у журнал у тому ж порядку, у якому змінюється сцена.
+func (s *Session) commit(r *http.Request, contentType, script string,
+	f func(*CurState) ([]painter.Operation, error), post func([]painter.Operation) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := s.state.clone()
+	ops, err := f(next)
+	if err != nil {
+		return err
+	}
+	if err := post(ops); err != nil {
+		return err
+	}
+
+	defer s.notify()()
+	*s.state = *next
+	if s.Log != nil {
+		e := LogEntry{Time: time.Now(), Remote: r.RemoteAddr, ContentType: contentType, Script: script}
+		if err := s.Log.Record(e); err != nil {
+			log.Printf("Cannot record script: %s", err)
+		}
+	}
+	return nil
+}
+
+// notify запам'ятовує фігури сцени і повертає функцію, яка розсилає події про їх зміни.
+// Викликається під s.mu.
+func (s *Session) notify() func() {
+	if !s.hub.active() {
+		return func() {}
+	}
+	before := *s.state
+	before.Figures = s.state.Figures.clone()
+	return func() { s.hub.publish(diffScene(&before, s.state)) }
+}
+
+// Undo скасовує n останніх змін сцени та повертає операції, які перемальовують відновлену сцену.
+func (s *Session) Undo(n int) ([]painter.Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.notify()()
+	return undo(s.state, n)
+}
+
+// Redo повторює n скасованих змін сцени.
+func (s *Session) Redo(n int) ([]painter.Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.notify()()
+	return redo(s.state, n)
+}
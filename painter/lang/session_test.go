@@ -0,0 +1,48 @@
+package lang_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func countFigures(ops []painter.Operation) int {
+	n := 0
+	for _, op := range ops {
+		if _, ok := op.(*painter.Figure); ok {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSessionKeepsStateBetweenScripts(t *testing.T) {
+	session := lang.NewSession()
+	parser := &lang.Parser{}
+
+	if _, err := session.Parse(parser, strings.NewReader("figure 0.1 0.1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ops, err := session.Parse(parser, strings.NewReader("figure 0.5 0.5\nupdate"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := countFigures(ops); n < 2 {
+		t.Errorf("expected figures from both scripts to be drawn, got %d", n)
+	}
+
+	if _, err := session.Parse(parser, strings.NewReader("reset")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ops, err = session.Parse(parser, strings.NewReader("update"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := countFigures(ops); n != 0 {
+		t.Errorf("expected no figures after reset, got %d", n)
+	}
+}
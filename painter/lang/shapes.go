@@ -0,0 +1,137 @@
+package lang
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// Найбільша товщина контуру в пікселях.
+const maxStrokeWidth = 1000
+
+// shapeOptions — параметри виду key=value, які приймає кожна фігура.
+var shapeOptions = map[string][]string{
+	"circle":   {"fill", "stroke", "width"},
+	"ellipse":  {"fill", "stroke", "width"},
+	"line":     {"stroke", "width"},
+	"polyline": {"stroke", "width"},
+	"polygon":  {"fill", "stroke", "width"},
+	"text":     {"size", "color", "align", "font"},
+}
+
+// parseShape створює фігуру з нормалізованих координат args, відображаючи їх на полотно розміру size.
+// Радіус кола відраховується від меншої сторони полотна, щоб коло лишалося колом.
+func (p *Parser) parseShape(cmd command, args []token, size image.Point) (painter.Shape, *SyntaxError) {
+	sx, sy := float64(size.X), float64(size.Y)
+	if cmd.name.text == "text" {
+		return p.parseText(cmd, args, sx, sy)
+	}
+
+	nums, opts := splitOptions(args)
+	style, err := p.style(cmd, opts)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := cmd.floats(nums)
+	if err != nil {
+		return nil, err
+	}
+
+	x := func(v float64) int { return int(v * sx) }
+	y := func(v float64) int { return int(v * sy) }
+
+	switch name := cmd.name.text; name {
+	case "circle":
+		if err := cmd.numbers(nums, 3); err != nil {
+			return nil, err
+		}
+		r := int(vals[2] * math.Min(sx, sy))
+		return &painter.Circle{X: x(vals[0]), Y: y(vals[1]), R: r, Style: style}, nil
+
+	case "ellipse":
+		if err := cmd.numbers(nums, 4); err != nil {
+			return nil, err
+		}
+		return &painter.Ellipse{X: x(vals[0]), Y: y(vals[1]), RX: x(vals[2]), RY: y(vals[3]), Style: style}, nil
+
+	case "line":
+		if err := cmd.numbers(nums, 4); err != nil {
+			return nil, err
+		}
+		return &painter.Line{X1: x(vals[0]), Y1: y(vals[1]), X2: x(vals[2]), Y2: y(vals[3]), Style: style}, nil
+
+	default:
+		minPoints := 2
+		if name == "polygon" {
+			minPoints = 3
+		}
+		if len(vals)%2 != 0 || len(vals) < 2*minPoints {
+			return nil, cmd.errorAt(cmd.end,
+				fmt.Sprintf("expected at least %d coordinate pairs, got %d numbers", minPoints, len(vals)))
+		}
+		pts := make([]image.Point, len(vals)/2)
+		for i := range pts {
+			pts[i] = image.Pt(x(vals[2*i]), y(vals[2*i+1]))
+		}
+		if name == "polygon" {
+			return &painter.Polygon{Points: pts, Style: style}, nil
+		}
+		return &painter.Polyline{Points: pts, Style: style}, nil
+	}
+}
+
+// splitOptions відокремлює параметри виду key=value у кінці списку аргументів. Слова в лапках не є параметрами.
+func splitOptions(args []token) (nums, opts []token) {
+	i := len(args)
+	for i > 0 && !args[i-1].quoted && strings.Contains(args[i-1].text, "=") {
+		i--
+	}
+	return args[:i], args[i:]
+}
+
+func (p *Parser) style(cmd command, opts []token) (painter.Style, *SyntaxError) {
+	var style painter.Style
+	allowed := shapeOptions[cmd.name.text]
+
+	for _, opt := range opts {
+		key, value, _ := strings.Cut(opt.text, "=")
+		if !slices.Contains(allowed, key) {
+			return style, cmd.errorAt(opt, fmt.Sprintf("unknown option %q for %s", key, cmd.name.text))
+		}
+		switch key {
+		case "fill", "stroke":
+			c, err := ParseColor(value, p.Palette)
+			if err != nil {
+				return style, cmd.errorAt(opt, err.Error())
+			}
+			if key == "fill" {
+				style.Fill = c
+			} else {
+				style.Stroke = c
+			}
+		case "width":
+			w, err := strconv.Atoi(value)
+			if err != nil || w <= 0 || w > maxStrokeWidth {
+				return style, cmd.errorAt(opt, fmt.Sprintf("width must be an integer from 1 to %d", maxStrokeWidth))
+			}
+			style.Width = w
+		}
+	}
+	return style, nil
+}
+
+// numbers перевіряє, що команда отримала рівно count числових аргументів.
+func (cmd command) numbers(nums []token, count int) *SyntaxError {
+	switch {
+	case len(nums) < count:
+		return cmd.errorAt(cmd.end, fmt.Sprintf("expected %d numeric args, got %d", count, len(nums)))
+	case len(nums) > count:
+		return cmd.errorAt(nums[count], fmt.Sprintf("expected %d numeric args, got %d", count, len(nums)))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package lang_test
+
+import (
+	"errors"
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+func TestParseShapes(t *testing.T) {
+	input := `canvas 200 100
+circle c 0.5 0.5 0.25 fill=red stroke=#0000ff width=3
+ellipse 0.5 0.5 0.1 0.2
+line l 0 0 1 1 stroke=green
+polyline 0 0 0.5 0.5 1 0
+polygon p 0 0 1 0 0.5 1 fill=white
+move c 0.1 0`
+
+	state := lang.UpdateState()
+	parser := &lang.Parser{}
+
+	if _, err := parser.Parse(strings.NewReader(input), state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if state.Figures.Len() != 5 {
+		t.Fatalf("expected 5 shapes, got %v", state.Figures.IDs())
+	}
+
+	c, _ := state.Figures.Get("c")
+	circle, ok := c.(*painter.Circle)
+	if !ok {
+		t.Fatalf("expected circle, got %T", c)
+	}
+	if circle.X != 120 || circle.Y != 50 || circle.R != 25 {
+		t.Errorf("unexpected circle geometry %+v", circle)
+	}
+	if circle.Fill != lang.DefaultPalette["red"] || circle.Stroke == nil || circle.Width != 3 {
+		t.Errorf("unexpected circle style %+v", circle.Style)
+	}
+
+	p, _ := state.Figures.Get("p")
+	polygon, ok := p.(*painter.Polygon)
+	if !ok {
+		t.Fatalf("expected polygon, got %T", p)
+	}
+	want := []image.Point{{0, 0}, {200, 0}, {100, 100}}
+	if !reflect.DeepEqual(polygon.Points, want) {
+		t.Errorf("polygon points %v, want %v", polygon.Points, want)
+	}
+}
+
+func TestParseShapeErrors(t *testing.T) {
+	tests := map[string]string{
+		"circle 0.5 0.5":                     "",
+		"circle 0.5 0.5 0.1 0.1":             "0.1",
+		"line 0 0 1 1 fill=red":              "fill=red",
+		"circle 0.5 0.5 0.1 width=0":         "width=0",
+		"polygon 0 0 1 1":                    "",
+		"polyline 0 0 1":                     "",
+		"ellipse 0.5 0.5 0.1 0.1 stroke=bad": "stroke=bad",
+	}
+	for input, tok := range tests {
+		_, err := (&lang.Parser{}).Parse(strings.NewReader(input), lang.UpdateState())
+
+		var errs lang.SyntaxErrors
+		if !errors.As(err, &errs) || len(errs) != 1 {
+			t.Errorf("%q: expected a single syntax error, got %v", input, err)
+			continue
+		}
+		if errs[0].Token != tok {
+			t.Errorf("%q: error points to %q, want %q", input, errs[0].Token, tok)
+		}
+	}
+}
+
+func TestJSONParser_Shapes(t *testing.T) {
+	input := `[
+		{"op": "polyline", "id": "path", "points": [[0, 0], [0.5, 0.5], [1, 0]], "stroke": "red", "width": 2},
+		{"op": "circle", "x": 0.5, "y": 0.5, "r": 0.1, "fill": "#00ff00"}
+	]`
+
+	state := lang.UpdateState()
+	if _, err := (&lang.JSONParser{}).Parse(strings.NewReader(input), state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	shape, _ := state.Figures.Get("path")
+	path, ok := shape.(*painter.Polyline)
+	if !ok || len(path.Points) != 3 || path.Width != 2 {
+		t.Errorf("unexpected polyline %+v", shape)
+	}
+	if state.Figures.Len() != 2 {
+		t.Errorf("expected 2 shapes, got %d", state.Figures.Len())
+	}
+}
+
+func TestParseText(t *testing.T) {
+	input := `text title 0.5 0.1 "Hello, world" size=24 color=red align=center
+text 0.1 0.9 "x=1" font=basic
+text 0.2 0.2 caption`
+
+	state := lang.UpdateState()
+	if _, err := (&lang.Parser{}).Parse(strings.NewReader(input), state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	shape, _ := state.Figures.Get("title")
+	title, ok := shape.(*painter.Text)
+	if !ok {
+		t.Fatalf("expected text, got %T", shape)
+	}
+	if title.Text != "Hello, world" || title.Size != 24 || title.Align != painter.AlignCenter || title.X != 200 {
+		t.Errorf("unexpected text %+v", title)
+	}
+
+	var texts []string
+	for _, id := range state.Figures.IDs() {
+		shape, _ := state.Figures.Get(id)
+		if txt, ok := shape.(*painter.Text); ok {
+			texts = append(texts, txt.Text)
+		}
+	}
+	if !reflect.DeepEqual(texts, []string{"Hello, world", "x=1", "caption"}) {
+		t.Errorf("unexpected texts %q", texts)
+	}
+
+	for _, bad := range []string{`text 0.1 0.1 "unterminated`, `text 0.1 0.1 two words`, `text 0.1 0.1 "a" align=top`} {
+		if _, err := (&lang.Parser{}).Parse(strings.NewReader(bad), lang.UpdateState()); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package lang
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/internal/sse"
+)
+
+// EventsHandler конструює обробник, який надсилає зміни сцени сесії s потоком Server-Sent Events.
+// Кожна подія scene містить SceneEvent у форматі JSON.
+func EventsHandler(s *Session) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		events, cancel := s.Subscribe()
+		defer cancel()
+
+		stream, ok := sse.Start(rw, r)
+		if !ok {
+			return
+		}
+		defer stream.Stop()
+
+		for {
+			select {
+			case e := <-events:
+				data, _ := json.Marshal(e)
+				if stream.Send("scene", data) != nil {
+					return
+				}
+			case <-stream.KeepAlive:
+				if stream.Comment() != nil {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package lang_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/lang"
+)
+
+// readEvent читає наступну подію Server-Sent Events і повертає її назву та дані.
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// openStream відкриває потік подій, який закривається після завершення тесту.
+func openStream(t *testing.T, h http.Handler, path string) *bufio.Reader {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+func TestEventsHandler(t *testing.T) {
+	session := lang.NewSession()
+	stream := openStream(t, lang.EventsHandler(session), "/stream/events")
+
+	parser := &lang.Parser{}
+	for _, script := range []string{"figure a 0.5 0.5\ncircle c 0.1 0.1 0.1", "move a 0.1 0", "delete c", "reset"} {
+		if _, err := session.Parse(parser, strings.NewReader(script)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	want := []lang.SceneEvent{
+		{Type: lang.EventAdded, ID: "a", Kind: "figure", X: 200, Y: 200},
+		{Type: lang.EventAdded, ID: "c", Kind: "circle", X: 40, Y: 40},
+		{Type: lang.EventMoved, ID: "a", Kind: "figure", X: 240, Y: 200},
+		{Type: lang.EventRemoved, ID: "c", Kind: "circle", X: 40, Y: 40},
+		{Type: lang.EventReset},
+	}
+	for i, w := range want {
+		event, data := readEvent(t, stream)
+		var got lang.SceneEvent
+		if err := json.Unmarshal([]byte(data), &got); event != "scene" || err != nil {
+			t.Fatalf("event %d: expected scene event, got %q %q", i, event, data)
+		}
+		if got != w {
+			t.Errorf("event %d: got %+v, want %+v", i, got, w)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package lang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/roman-mazur/architecture-lab-3/painter"
+)
+
+// Найбільший кегль напису в пікселях.
+const maxTextSize = 1000
+
+var textAligns = map[string]painter.Align{
+	"left":   painter.AlignLeft,
+	"center": painter.AlignCenter,
+	"right":  painter.AlignRight,
+}
+
+var textFonts = map[string]painter.Font{
+	"go":    painter.FontGo,
+	"basic": painter.FontBasic,
+}
+
+// parseText створює напис: x y "текст" [size=<px>] [color=<color>] [align=left|center|right] [font=go|basic].
+func (p *Parser) parseText(cmd command, args []token, sx, sy float64) (painter.Shape, *SyntaxError) {
+	nums, opts := splitOptions(args)
+	if len(nums) < 3 {
+		return nil, cmd.errorAt(cmd.end, fmt.Sprintf("expected x, y and text, got %d args", len(nums)))
+	}
+	if len(nums) > 3 {
+		return nil, cmd.errorAt(nums[3], "text with spaces must be quoted")
+	}
+	vals, err := cmd.floats(nums[:2])
+	if err != nil {
+		return nil, err
+	}
+
+	txt := &painter.Text{X: int(vals[0] * sx), Y: int(vals[1] * sy), Text: nums[2].text}
+	for _, opt := range opts {
+		key, value, _ := strings.Cut(opt.text, "=")
+		switch key {
+		case "size":
+			size, err := strconv.ParseFloat(value, 64)
+			if err != nil || !(size > 0 && size <= maxTextSize) {
+				return nil, cmd.errorAt(opt, fmt.Sprintf("size must be a number from 0 to %d", maxTextSize))
+			}
+			txt.Size = size
+		case "color":
+			c, err := p.color(cmd, token{text: value, col: opt.col})
+			if err != nil {
+				return nil, err
+			}
+			txt.Color = c
+		case "align":
+			align, ok := textAligns[value]
+			if !ok {
+				return nil, cmd.errorAt(opt, "align must be left, center or right")
+			}
+			txt.Align = align
+		case "font":
+			f, ok := textFonts[value]
+			if !ok {
+				return nil, cmd.errorAt(opt, "font must be go or basic")
+			}
+			txt.Font = f
+		default:
+			return nil, cmd.errorAt(opt, fmt.Sprintf("unknown option %q for text", key))
+		}
+	}
+	return txt, nil
+}
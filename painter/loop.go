@@ -1,8 +1,13 @@
 package painter
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"image"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/exp/shiny/screen"
 )
@@ -12,70 +17,497 @@ type Receiver interface {
 	Update(t screen.Texture)
 }
 
+// DefaultCanvasSize — розмір текстури, якщо Loop.Size не задано.
+var DefaultCanvasSize = image.Pt(400, 400)
+
 // Loop реалізує цикл подій для формування текстури отриманої через виконання операцій отриманих з внутрішньої черги.
 type Loop struct {
 	Receiver Receiver
 
-	next screen.Texture // текстура, яка зараз формується
-	prev screen.Texture // текстура, яка була відправленя останнього разу у Receiver
+	// Size — розмір текстур, у яких формується зображення. Якщо не задано, використовується DefaultCanvasSize.
+	Size image.Point
+	// AnimationFPS — частота кадрів анімацій. Якщо не задано, використовується DefaultAnimationFPS.
+	AnimationFPS int
+	// MaxFPS обмежує кількість кадрів за секунду, які отримує Receiver. Кадр, готовий раніше ніж через
+	// 1/MaxFPS після попереднього, відкладається, а якщо до того часу готовий наступний — відкидається.
+	// Нуль означає відсутність обмеження.
+	MaxFPS int
+	// QueueSize обмежує кількість операцій, що очікують у черзі. Нуль означає необмежену чергу.
+	QueueSize int
+	// Overflow визначає, що робить Post, коли черга заповнена.
+	Overflow OverflowPolicy
+	// OnError отримує помилки операцій, зокрема PanicError, з горутини циклу подій.
+	// Якщо не задано, помилки записуються в журнал. Після помилки Loop продовжує обробляти чергу.
+	OnError func(op Operation, err error)
+
+	screen  screen.Screen
+	next    screen.Texture // текстура, яка зараз формується
+	prev    screen.Texture // текстура, яка була відправленя останнього разу у Receiver
+	stale   screen.Texture // текстура старого розміру, яку Receiver ще може використовувати
+	pending screen.Texture // готовий кадр, відкладений через обмеження частоти кадрів
+	spare   screen.Texture // вільна текстура для відкладеного кадру
+
+	lastFrame time.Time
+	updates   int        // оновлення, що надійшли після останнього сформованого кадру
+	waiting   []*tracked // операції, що чекають на показ кадру
+	opErr     error      // остання помилка операції, яку отримає PostFuture
+	stats     struct{ frames, coalesced, dropped, errors atomic.Uint64 }
 
 	mq messageQueue
 
-	stopped chan struct{}
-	stopReq bool
+	animations map[string]*animation // запущені анімації, доступні лише з горутини циклу подій
+	idle       []animationsIdle      // очікування WaitAnimations, доступні лише з горутини циклу подій
+
+	life     sync.Mutex    // захищає started, stopping та створення stopped
+	started  bool          // Start вже викликано
+	stopping bool          // зупинку вже запитано
+	stopped  chan struct{} // закривається, коли цикл подій завершився
+	stopReq  bool          // доступне лише з горутини циклу подій
 }
 
-var size = image.Pt(400, 400)
+// ErrLoopStopped повертає Post, якщо зупинку Loop вже запитано.
+var ErrLoopStopped = errors.New("painter: loop is stopped")
 
+// Start запускає цикл подій. Повторні виклики, як і виклик після Stop, нічого не роблять.
 func (l *Loop) Start(s screen.Screen) {
+	l.StartContext(context.Background(), s)
+}
+
+// StartContext запускає цикл подій, який зупиняється, коли завершується ctx, так само як після Stop.
+func (l *Loop) StartContext(ctx context.Context, s screen.Screen) {
+	l.life.Lock()
+	defer l.life.Unlock()
+	if l.started || l.stopping {
+		return
+	}
+	l.started = true
+	stopped := l.done()
+
+	size := l.Size
+	if size == (image.Point{}) {
+		size = DefaultCanvasSize
+	}
+	l.screen = s
 	l.next, _ = s.NewTexture(size)
 	l.prev, _ = s.NewTexture(size)
 
-	l.stopped = make(chan struct{})
+	l.mq.setLimit(l.QueueSize, l.Overflow)
+
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				l.Stop()
+			case <-stopped:
+			}
+		}()
+	}
 
 	go func() {
 		for !l.stopReq || !l.mq.empty() {
-			op := l.mq.pull()
-			update := op.Do(l.next)
-			if update {
-				l.Receiver.Update(l.next)
-				l.next, l.prev = l.prev, l.next
+			var op Operation
+			if l.pending == nil {
+				op = l.mq.pull()
+			} else {
+				wait := time.Until(l.lastFrame.Add(l.frameInterval()))
+				var ok bool
+				if op, ok = l.mq.pullTimeout(wait); !ok {
+					l.flush()
+					continue
+				}
+			}
+			if l.do(op) {
+				l.frame()
 			}
 		}
-		close(l.stopped)
+		l.flush()
+		l.stopAnimations("*")
+		close(stopped)
 	}()
+}
+
+// done повертає канал, який закривається після завершення циклу подій. Викликається під l.life.
+func (l *Loop) done() chan struct{} {
+	if l.stopped == nil {
+		l.stopped = make(chan struct{})
+	}
+	return l.stopped
+}
+
+// Done повертає канал, який закривається, коли цикл подій завершився після Stop
+// або відразу після Stop, якщо Loop так і не було запущено.
+func (l *Loop) Done() <-chan struct{} {
+	l.life.Lock()
+	defer l.life.Unlock()
+	return l.done()
+}
+
+// Stop запитує зупинку циклу подій і не чекає на неї. Операції, що вже є в черзі, буде виконано,
+// а нові Post відхиляє з ErrLoopStopped. Повторні виклики нічого не роблять.
+func (l *Loop) Stop() {
+	l.life.Lock()
+	defer l.life.Unlock()
+	if l.stopping {
+		return
+	}
+	l.stopping = true
+
+	l.mq.close()
+	if !l.started {
+		close(l.done())
+		return
+	}
+	// Службові операції додаються в чергу навіть тоді, коли вона заповнена чи закрита.
+	_ = l.mq.push(stopRequest{}, true)
+}
 
+// StopAndWait зупиняє цикл подій і чекає на завершення операцій з черги. Якщо ctx завершується раніше,
+// повертається його помилка, а цикл подій завершиться пізніше.
+func (l *Loop) StopAndWait(ctx context.Context) error {
+	l.Stop()
+	select {
+	case <-l.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OverflowPolicy визначає поведінку Post, коли черга операцій заповнена.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock змушує Post чекати, доки в черзі звільниться місце.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest відкидає найстарішу операцію в черзі, щоб додати нову.
+	OverflowDropOldest
+	// OverflowDropNewest відкидає нову операцію, Post повертає ErrDropped.
+	OverflowDropNewest
+	// OverflowReject відхиляє нову операцію, Post повертає ErrQueueFull.
+	OverflowReject
+)
+
+var overflowPolicyNames = map[OverflowPolicy]string{
+	OverflowBlock:      "block",
+	OverflowDropOldest: "drop-oldest",
+	OverflowDropNewest: "drop-newest",
+	OverflowReject:     "reject",
 }
 
-func (l *Loop) Post(op Operation) {
-	if op != nil {
-		l.mq.push(op)
+func (p OverflowPolicy) String() string {
+	if name, ok := overflowPolicyNames[p]; ok {
+		return name
 	}
+	return fmt.Sprintf("OverflowPolicy(%d)", int(p))
 }
 
-func (l *Loop) StopAndWait() {
-	l.Post(OperationFunc(func(screen.Texture) {
+// ParseOverflowPolicy повертає політику за її назвою: block, drop-oldest, drop-newest або reject.
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	for p, n := range overflowPolicyNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown overflow policy: %s", name)
+}
+
+var (
+	// ErrQueueFull означає, що черга заповнена і клієнту варто повторити спробу пізніше.
+	ErrQueueFull = errors.New("painter: operation queue is full")
+	// ErrDropped означає, що операцію відкинуто, бо Loop не встигає обробляти черги.
+	ErrDropped = errors.New("painter: operation dropped, loop is overloaded")
+)
+
+// Stats — лічильники кадрів Loop.
+type Stats struct {
+	// Frames — кадри, передані у Receiver.
+	Frames uint64 `json:"frames"`
+	// Coalesced — оновлення, які не дали окремого кадру, бо надійшли разом з іншими, наприклад в одному OperationList.
+	Coalesced uint64 `json:"coalesced"`
+	// Dropped — сформовані кадри, відкинуті через обмеження MaxFPS, бо до їх показу був готовий новіший кадр.
+	Dropped uint64 `json:"dropped"`
+	// DroppedOps — операції, відкинуті або відхилені через переповнення черги.
+	DroppedOps uint64 `json:"dropped_ops"`
+	// Errors — помилки та паніки операцій.
+	Errors uint64 `json:"errors"`
+}
+
+// Stats повертає лічильники кадрів. Метод можна викликати з будь-якої горутини.
+func (l *Loop) Stats() Stats {
+	return Stats{
+		Frames:    l.stats.frames.Load(),
+		Coalesced: l.stats.coalesced.Load(),
+		Dropped:   l.stats.dropped.Load(),
+
+		DroppedOps: l.mq.dropped.Load(),
+		Errors:     l.stats.errors.Load(),
+	}
+}
+
+func (l *Loop) frameInterval() time.Duration {
+	if l.MaxFPS <= 0 {
+		return 0
+	}
+	return time.Second / time.Duration(l.MaxFPS)
+}
+
+// frame обробляє сформований кадр: передає його у Receiver або відкладає, якщо попередній кадр був надто недавно.
+func (l *Loop) frame() {
+	if l.updates > 1 {
+		l.stats.coalesced.Add(uint64(l.updates - 1))
+	}
+	l.updates = 0
+
+	if l.pending == nil && time.Since(l.lastFrame) >= l.frameInterval() {
+		l.present(l.next)
+		l.next, l.prev = l.prev, l.next
+		return
+	}
+
+	if l.pending != nil {
+		// Відкладений кадр так і не був показаний, його місце займає новіший.
+		l.stats.dropped.Add(1)
+		l.next, l.pending = l.pending, l.next
+		return
+	}
+	if l.spare == nil {
+		spare, err := l.screen.NewTexture(l.next.Size())
+		if err != nil {
+			// Без вільної текстури кадр неможливо відкласти, тож він показується одразу.
+			l.present(l.next)
+			l.next, l.prev = l.prev, l.next
+			return
+		}
+		l.spare = spare
+	}
+	l.pending, l.next, l.spare = l.next, l.spare, nil
+}
+
+// flush показує відкладений кадр, якщо він є.
+func (l *Loop) flush() {
+	if l.pending == nil {
+		return
+	}
+	l.present(l.pending)
+	l.pending, l.prev, l.spare = nil, l.pending, l.prev
+}
+
+func (l *Loop) present(t screen.Texture) {
+	l.Receiver.Update(t)
+	l.presented()
+	l.lastFrame = time.Now()
+	l.stats.frames.Add(1)
+	if l.stale != nil {
+		l.stale.Release()
+		l.stale = nil
+	}
+}
+
+// do виконує операцію. Операції, яким потрібен доступ до самого циклу, обробляються тут, зокрема всередині OperationList.
+func (l *Loop) do(op Operation) (ready bool) {
+	switch op := op.(type) {
+	case nil:
+		return false
+	case stopRequest:
 		l.stopReq = true
-	}))
-	<-l.stopped
+		return false
+	case *tracked:
+		return l.doTracked(op)
+	case OperationList:
+		for _, o := range op {
+			ready = l.do(o) || ready
+		}
+		return ready
+	case CanvasOp:
+		l.resize(op.Size)
+		return false
+	case AnimateOp:
+		l.startAnimation(op.Animation)
+		return false
+	case StopAnimationOp:
+		l.stopAnimations(op.ID)
+		return false
+	case animationsIdle:
+		l.idle = append(l.idle, op)
+		l.notifyIdle()
+		return false
+	case animationDone:
+		if l.animations[op.a.ID] == op.a {
+			delete(l.animations, op.a.ID)
+			l.notifyIdle()
+		}
+		if op.err != nil {
+			l.report(AnimateOp{op.a.Animation}, op.err)
+		}
+		return false
+	default:
+		if !l.exec(op) {
+			return false
+		}
+		l.updates++
+		return true
+	}
+}
+
+// resize створює текстури нового розміру. Попередня текстура звільняється лише після наступного кадру,
+// бо Receiver може досі її показувати.
+func (l *Loop) resize(size image.Point) {
+	if size.X <= 0 || size.Y <= 0 || size == l.next.Size() {
+		return
+	}
+	// Відкладений кадр старого розміру показується одразу, щоб не змішувати текстури різних розмірів.
+	l.flush()
+	next, err := l.screen.NewTexture(size)
+	if err != nil {
+		return
+	}
+	prev, err := l.screen.NewTexture(size)
+	if err != nil {
+		next.Release()
+		return
+	}
+
+	l.next.Release()
+	if l.spare != nil {
+		l.spare.Release()
+		l.spare = nil
+	}
+	if l.stale != nil {
+		l.stale.Release()
+	}
+	l.stale = l.prev
+	l.next, l.prev = next, prev
+}
+
+// Post додає операцію в чергу. Якщо черга заповнена, результат залежить від Overflow:
+// Post чекає на місце, відкидає найстарішу операцію або повертає ErrDropped чи ErrQueueFull.
+// Після Stop операції не приймаються, а Post повертає ErrLoopStopped.
+func (l *Loop) Post(op Operation) error {
+	if op == nil {
+		return nil
+	}
+	return l.mq.push(op, false)
 }
 
+// stopRequest зупиняє цикл подій після обробки операцій, що вже є в черзі.
+type stopRequest struct{}
+
+func (stopRequest) Do(t screen.Texture) bool { return false }
+
 type messageQueue struct {
 	ops   []Operation
 	mut   sync.Mutex
-	noacs chan struct{}
+	noacs chan struct{} // закривається, коли в порожній черзі з'являється операція
+	space chan struct{} // закривається, коли в заповненій черзі звільняється місце
+
+	limit   int
+	policy  OverflowPolicy
+	closed  bool // після закриття приймаються лише службові операції
+	dropped atomic.Uint64
 }
 
-func (mq *messageQueue) push(op Operation) {
+// close відхиляє подальші операції та звільняє тих, хто чекає на місце в черзі.
+func (mq *messageQueue) close() {
 	mq.mut.Lock()
 	defer mq.mut.Unlock()
 
+	mq.closed = true
+	mq.removed()
+}
+
+func (mq *messageQueue) setLimit(limit int, policy OverflowPolicy) {
+	mq.mut.Lock()
+	defer mq.mut.Unlock()
+
+	mq.limit, mq.policy = limit, policy
+}
+
+// push додає операцію в чергу з урахуванням обмеження розміру. Службові операції (force) додаються завжди
+// і ніколи не відкидаються.
+func (mq *messageQueue) push(op Operation, force bool) error {
+	mq.mut.Lock()
+	defer mq.mut.Unlock()
+
+	for !force {
+		if mq.closed {
+			return ErrLoopStopped
+		}
+		if mq.limit <= 0 || mq.queued() < mq.limit {
+			break
+		}
+		switch mq.policy {
+		case OverflowDropOldest:
+			if !mq.dropOldest() {
+				// У черзі лише службові операції, тож нова операція відкидається замість них.
+				mq.dropped.Add(1)
+				return ErrDropped
+			}
+		case OverflowDropNewest:
+			mq.dropped.Add(1)
+			return ErrDropped
+		case OverflowReject:
+			mq.dropped.Add(1)
+			return ErrQueueFull
+		default:
+			if mq.space == nil {
+				mq.space = make(chan struct{})
+			}
+			space := mq.space
+			mq.mut.Unlock()
+			<-space
+			mq.mut.Lock()
+		}
+	}
+
 	mq.ops = append(mq.ops, op)
 
 	if mq.noacs != nil {
 		close(mq.noacs)
 		mq.noacs = nil
 	}
+	return nil
+}
+
+// queued повертає кількість операцій у черзі без урахування службових.
+func (mq *messageQueue) queued() int {
+	n := 0
+	for _, op := range mq.ops {
+		if !internal(op) {
+			n++
+		}
+	}
+	return n
+}
+
+// dropOldest видаляє найстарішу операцію, що не є службовою.
+func (mq *messageQueue) dropOldest() bool {
+	for i, op := range mq.ops {
+		if !internal(op) {
+			mq.ops = append(mq.ops[:i], mq.ops[i+1:]...)
+			mq.dropped.Add(1)
+			if t, ok := op.(*tracked); ok {
+				t.resolve(ErrDropped)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// removed повідомляє операції, що чекають на місце в черзі, що воно звільнилося.
+func (mq *messageQueue) removed() {
+	if mq.space != nil {
+		close(mq.space)
+		mq.space = nil
+	}
+}
+
+func internal(op Operation) bool {
+	switch op.(type) {
+	case stopRequest, animationDone, animationsIdle:
+		return true
+	}
+	return false
 }
 
 func (mq *messageQueue) pull() Operation {
@@ -83,19 +515,50 @@ func (mq *messageQueue) pull() Operation {
 	defer mq.mut.Unlock()
 
 	for len(mq.ops) == 0 {
-		mq.noacs = make(chan struct{})
+		noacs := make(chan struct{})
+		mq.noacs = noacs
 		mq.mut.Unlock()
-		<-mq.noacs
+		<-noacs
 		mq.mut.Lock()
 	}
 
 	op_res := mq.ops[0]
 	mq.ops[0] = nil
 	mq.ops = mq.ops[1:]
+	mq.removed()
 
 	return op_res
 }
 
+// pullTimeout чекає на операцію не довше за d. Якщо за цей час черга лишилась порожньою, ok дорівнює false.
+func (mq *messageQueue) pullTimeout(d time.Duration) (op Operation, ok bool) {
+	mq.mut.Lock()
+	defer mq.mut.Unlock()
+
+	if len(mq.ops) == 0 && d > 0 {
+		noacs := make(chan struct{})
+		mq.noacs = noacs
+		mq.mut.Unlock()
+
+		timer := time.NewTimer(d)
+		select {
+		case <-noacs:
+		case <-timer.C:
+		}
+		timer.Stop()
+		mq.mut.Lock()
+	}
+	if len(mq.ops) == 0 {
+		return nil, false
+	}
+
+	op = mq.ops[0]
+	mq.ops[0] = nil
+	mq.ops = mq.ops[1:]
+	mq.removed()
+	return op, true
+}
+
 func (mq *messageQueue) empty() bool {
 	mq.mut.Lock()
 	defer mq.mut.Unlock()
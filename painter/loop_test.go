@@ -1,12 +1,15 @@
 package painter
 
 import (
+	"context"
 	"image"
 	"image/color"
 	"image/draw"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
 	"golang.org/x/exp/shiny/screen"
 )
 
@@ -18,6 +21,20 @@ func makeTestOp(name string, testOps *[]string) OperationFunc {
 	})
 }
 
+// startLoop запускає l на екрані s і зупиняє його після завершення тесту. Якщо Receiver не задано,
+// кадри отримує testReceiver, який і повертається.
+func startLoop(t *testing.T, l *Loop, s screen.Screen) *testReceiver {
+	t.Helper()
+	tr, _ := l.Receiver.(*testReceiver)
+	if l.Receiver == nil {
+		tr = &testReceiver{}
+		l.Receiver = tr
+	}
+	l.Start(s)
+	t.Cleanup(func() { _ = l.StopAndWait(context.Background()) })
+	return tr
+}
+
 func TestLoop_Post(t *testing.T) {
 	var (
 		l  Loop
@@ -35,7 +52,7 @@ func TestLoop_Post(t *testing.T) {
 
 	l.Post(WhiteBackgroundOp(color.White))
 	l.Post(UpdateOp)
-	l.StopAndWait()
+	l.StopAndWait(context.Background())
 
 	if tr.lastTexture == nil {
 		t.Fatal("Texture was not updated")
@@ -65,7 +82,7 @@ func TestLoop_StopAndWait(t *testing.T) {
 	l.Start(mockScreen{})
 	l.Post(WhiteBackgroundOp(color.White))
 
-	l.StopAndWait()
+	l.StopAndWait(context.Background())
 
 	if !l.stopReq {
 		t.Error("Loop.stopReq should be true after StopAndWait")
@@ -78,9 +95,242 @@ func TestLoop_StopAndWait(t *testing.T) {
 	}
 }
 
+func TestLoop_Offscreen(t *testing.T) {
+	var l Loop
+	tr := startLoop(t, &l, offscreen.NewScreen())
+	l.Post(WhiteBackgroundOp(color.White))
+	l.Post(Figure{X: 200, Y: 200})
+	l.Post(UpdateOp)
+	l.StopAndWait(context.Background())
+
+	img := offscreen.Snapshot(tr.lastTexture)
+	if img == nil {
+		t.Fatal("Texture was not updated with an offscreen texture")
+	}
+	if got := img.RGBAAt(0, 0); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("background pixel = %v, want white", got)
+	}
+	if got := img.RGBAAt(200, 200); got != (color.RGBA{R: 255, G: 230, B: 69, A: 255}) {
+		t.Errorf("figure pixel = %v, want yellow", got)
+	}
+}
+
+func TestLoop_Canvas(t *testing.T) {
+	l := Loop{Size: image.Pt(160, 90)}
+	tr := startLoop(t, &l, offscreen.NewScreen())
+	l.Post(UpdateOp)
+	l.Post(OperationList{CanvasOp{Size: image.Pt(320, 180)}, WhiteBackgroundOp(color.White), UpdateOp})
+	l.StopAndWait(context.Background())
+
+	img := offscreen.Snapshot(tr.lastTexture)
+	if img == nil {
+		t.Fatal("Texture was not updated with an offscreen texture")
+	}
+	if size := img.Bounds().Size(); size != image.Pt(320, 180) {
+		t.Errorf("expected texture of size 320x180 after CanvasOp, got %v", size)
+	}
+	if got := img.RGBAAt(319, 179); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("resized texture was not filled: %v", got)
+	}
+}
+
+func TestLoop_MaxFPS(t *testing.T) {
+	l := Loop{MaxFPS: 20}
+	tr := startLoop(t, &l, offscreen.NewScreen())
+	for i := 0; i < 30; i++ {
+		l.Post(OperationList{BackgroundOp(color.Gray{Y: uint8(i)}), UpdateOp})
+	}
+	l.Post(OperationList{BackgroundOp(color.White), UpdateOp, UpdateOp})
+	l.StopAndWait(context.Background())
+
+	stats := l.Stats()
+	if stats.Frames == 0 || stats.Frames > 3 {
+		t.Errorf("expected at most 3 frames for a burst of updates, got %d", stats.Frames)
+	}
+	if stats.Frames+stats.Dropped != 31 {
+		t.Errorf("every frame must be either presented or dropped: %+v", stats)
+	}
+	if stats.Coalesced != 1 {
+		t.Errorf("expected 1 coalesced update, got %d", stats.Coalesced)
+	}
+
+	img := offscreen.Snapshot(tr.lastTexture)
+	if got := img.RGBAAt(0, 0); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("last presented frame must be the latest one, got %v", got)
+	}
+}
+
+func TestLoop_MaxFPSDelaysFrame(t *testing.T) {
+	rec := &FrameRecorder{}
+	l := Loop{Receiver: rec, MaxFPS: 10}
+	startLoop(t, &l, offscreen.NewScreen())
+	l.Post(OperationList{BackgroundOp(color.Black), UpdateOp})
+	l.Post(OperationList{BackgroundOp(color.White), UpdateOp})
+
+	// Другий кадр відкладається, але з'являється без нових операцій, щойно мине інтервал.
+	deadline := time.Now().Add(time.Second)
+	for {
+		if img := rec.Frame(); img != nil && img.RGBAAt(0, 0) == (color.RGBA{255, 255, 255, 255}) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("delayed frame was not presented")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	l.StopAndWait(context.Background())
+
+	if stats := l.Stats(); stats.Frames != 2 || stats.Dropped != 0 {
+		t.Errorf("expected 2 presented frames, got %+v", stats)
+	}
+}
+
+// blockLoop запускає цикл з обмеженою чергою і займає його операцією, яка чекає на закриття release.
+func blockLoop(t *testing.T, size int, policy OverflowPolicy, release chan struct{}) *Loop {
+	t.Helper()
+	l := &Loop{QueueSize: size, Overflow: policy}
+	startLoop(t, l, mockScreen{})
+
+	started := make(chan struct{})
+	_ = l.Post(OperationFunc(func(screen.Texture) {
+		close(started)
+		<-release
+	}))
+	<-started
+	return l
+}
+
+func TestLoop_QueueOverflow(t *testing.T) {
+	for _, tc := range []struct {
+		policy OverflowPolicy
+		err    error
+		want   []string
+	}{
+		{OverflowReject, ErrQueueFull, []string{"op 1", "op 2"}},
+		{OverflowDropNewest, ErrDropped, []string{"op 1", "op 2"}},
+		{OverflowDropOldest, nil, []string{"op 2", "op 3"}},
+	} {
+		release := make(chan struct{})
+		l := blockLoop(t, 2, tc.policy, release)
+
+		var testOps []string
+		for i, name := range []string{"op 1", "op 2", "op 3"} {
+			err := l.Post(makeTestOp(name, &testOps))
+			if i < 2 && err != nil {
+				t.Errorf("policy %s: unexpected error for %s: %v", tc.policy, name, err)
+			}
+			if i == 2 && err != tc.err {
+				t.Errorf("policy %s: expected error %v, got %v", tc.policy, tc.err, err)
+			}
+		}
+		close(release)
+		l.StopAndWait(context.Background())
+
+		if !reflect.DeepEqual(testOps, tc.want) {
+			t.Errorf("policy %s: executed %v, want %v", tc.policy, testOps, tc.want)
+		}
+		if n := l.Stats().DroppedOps; n != 1 {
+			t.Errorf("policy %s: expected 1 dropped operation, got %d", tc.policy, n)
+		}
+	}
+}
+
+func TestLoop_QueueBlock(t *testing.T) {
+	release := make(chan struct{})
+	l := blockLoop(t, 1, OverflowBlock, release)
+
+	_ = l.Post(OperationFunc(func(screen.Texture) {}))
+	posted := make(chan error)
+	go func() { posted <- l.Post(OperationFunc(func(screen.Texture) {})) }()
+
+	select {
+	case <-posted:
+		t.Fatal("Post must block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-posted; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoop_StopBeforeStart(t *testing.T) {
+	var l Loop
+
+	if err := l.StopAndWait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l.Stop()
+	startLoop(t, &l, mockScreen{})
+
+	select {
+	case <-l.Done():
+	default:
+		t.Error("Done must be closed after stop")
+	}
+	if err := l.Post(UpdateOp); err != ErrLoopStopped {
+		t.Errorf("expected ErrLoopStopped, got %v", err)
+	}
+}
+
+func TestLoop_StopIdempotent(t *testing.T) {
+	var l Loop
+	startLoop(t, &l, mockScreen{})
+
+	l.Stop()
+	l.Stop()
+	for i := 0; i < 2; i++ {
+		if err := l.StopAndWait(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := l.Post(UpdateOp); err != ErrLoopStopped {
+		t.Errorf("expected ErrLoopStopped, got %v", err)
+	}
+}
+
+func TestLoop_StartContext(t *testing.T) {
+	var l Loop
+	l.Receiver = &testReceiver{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.StartContext(ctx, mockScreen{})
+	cancel()
+
+	select {
+	case <-l.Done():
+	case <-time.After(time.Second):
+		t.Fatal("loop did not stop after context cancellation")
+	}
+}
+
+func TestLoop_StopAndWaitTimeout(t *testing.T) {
+	release := make(chan struct{})
+	l := blockLoop(t, 1, OverflowBlock, release)
+
+	_ = l.Post(OperationFunc(func(screen.Texture) {}))
+	posted := make(chan error)
+	go func() { posted <- l.Post(OperationFunc(func(screen.Texture) {})) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.StopAndWait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline error while an operation is running, got %v", err)
+	}
+	if err := <-posted; err != ErrLoopStopped {
+		t.Errorf("blocked Post must fail after stop, got %v", err)
+	}
+
+	close(release)
+	if err := l.StopAndWait(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 func TestBgRect_Do(t *testing.T) {
 	mt := &mockTexture{}
-	op := BgRect{10, 20, 30, 40}
+	op := BgRect{X1: 10, Y1: 20, X2: 30, Y2: 40}
 
 	op.Do(mt)
 
@@ -97,6 +347,16 @@ func TestBgRect_Do(t *testing.T) {
 	}
 }
 
+func TestBgRect_DoColor(t *testing.T) {
+	mt := &mockTexture{}
+	red := color.RGBA{R: 255, A: 255}
+	BgRect{X2: 10, Y2: 10, Color: red}.Do(mt)
+
+	if len(mt.Colors) != 1 || mt.Colors[0] != red {
+		t.Errorf("expected a single red fill, got %v", mt.Colors)
+	}
+}
+
 func TestFigure_Do(t *testing.T) {
 	mt := &mockTexture{}
 	op := Figure{X: 100, Y: 100}
@@ -193,7 +453,7 @@ type mockTexture struct {
 
 func (m *mockTexture) Release() {}
 
-func (m *mockTexture) Size() image.Point { return size }
+func (m *mockTexture) Size() image.Point { return DefaultCanvasSize }
 
 func (m *mockTexture) Bounds() image.Rectangle {
 	return image.Rectangle{Max: m.Size()}
@@ -0,0 +1,113 @@
+package painter
+
+import (
+	"image"
+	"log"
+	"sync"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"golang.org/x/exp/shiny/screen"
+)
+
+// MultiReceiver передає кожен кадр усім доданим Receiver. Кожен отримувач працює у власній горутині
+// з чергою на один кадр: поки він обробляє попередній кадр, проміжні кадри пропускаються, тож повільний
+// отримувач не затримує Loop та інших отримувачів. Отримувачі бачать копію кадру, яку Loop більше не змінює.
+// Скопіювати можна лише текстури пакета offscreen, тож Loop для MultiReceiver має малювати на offscreen.Screen.
+// Нульове значення готове до використання.
+type MultiReceiver struct {
+	mu     sync.Mutex
+	subs   map[Receiver]*subscriber
+	warned bool // про текстуру, яку не можна скопіювати, вже записано в журнал
+}
+
+// subscriber доставляє кадри одному отримувачу.
+type subscriber struct {
+	r      Receiver
+	frames chan screen.Texture
+	quit   chan struct{}
+}
+
+func (s *subscriber) run() {
+	for {
+		select {
+		case t := <-s.frames:
+			s.r.Update(t)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// AddReceiver додає отримувача. Повторне додавання того самого отримувача нічого не змінює.
+func (m *MultiReceiver) AddReceiver(r Receiver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.subs[r]; ok {
+		return
+	}
+	if m.subs == nil {
+		m.subs = make(map[Receiver]*subscriber)
+	}
+	s := &subscriber{r: r, frames: make(chan screen.Texture, 1), quit: make(chan struct{})}
+	m.subs[r] = s
+	go s.run()
+}
+
+// RemoveReceiver прибирає отримувача. Кадр, який він уже обробляє, буде оброблено до кінця,
+// а решта кадрів до нього не надійде.
+func (m *MultiReceiver) RemoveReceiver(r Receiver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.subs[r]; ok {
+		close(s.quit)
+		delete(m.subs, r)
+	}
+}
+
+// Close прибирає всіх отримувачів.
+func (m *MultiReceiver) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for r, s := range m.subs {
+		close(s.quit)
+		delete(m.subs, r)
+	}
+}
+
+// Update копіює кадр і передає копію кожному отримувачу, не чекаючи на них. Без отримувачів кадр не копіюється.
+// Текстури, які не можна скопіювати, не розсилаються: Loop міг би змінити їх, поки отримувачі ще читають кадр.
+func (m *MultiReceiver) Update(t screen.Texture) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.subs) == 0 {
+		return
+	}
+	img := offscreen.Snapshot(t)
+	if img == nil {
+		if !m.warned {
+			m.warned = true
+			log.Printf("MultiReceiver: cannot copy %T, frames are not delivered; render on offscreen.Screen", t)
+		}
+		return
+	}
+	m.offerAll(img)
+}
+
+// updateCopy розсилає кадр, який уже скопійовано і який більше ніхто не змінює, без повторного копіювання.
+func (m *MultiReceiver) updateCopy(img *image.RGBA) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.offerAll(img)
+}
+
+func (m *MultiReceiver) offerAll(img *image.RGBA) {
+	frame := offscreen.NewTextureFrom(img)
+	for _, s := range m.subs {
+		// Непрочитаний кадр замінюється новішим, щоб Update не чекав на отримувача.
+		offer(s.frames, screen.Texture(frame))
+	}
+}
@@ -0,0 +1,111 @@
+package painter
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+	"time"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"golang.org/x/exp/shiny/screen"
+)
+
+// chanReceiver передає отримані текстури у канал і чекає, доки їх прочитають.
+type chanReceiver chan screen.Texture
+
+func (r chanReceiver) Update(t screen.Texture) { r <- t }
+
+func TestMultiReceiver(t *testing.T) {
+	var m MultiReceiver
+	slow, fast := make(chanReceiver), make(chanReceiver, 10)
+	m.AddReceiver(slow)
+	m.AddReceiver(fast)
+	m.AddReceiver(fast)
+
+	tx, _ := offscreen.NewScreen().NewTexture(image.Pt(4, 4))
+	updated := make(chan struct{})
+	go func() {
+		for _, c := range []color.Color{color.Black, color.White, color.Black} {
+			tx.Fill(tx.Bounds(), c, draw.Src)
+			m.Update(tx)
+			time.Sleep(10 * time.Millisecond)
+		}
+		close(updated)
+	}()
+
+	select {
+	case <-updated:
+	case <-time.After(time.Second):
+		t.Fatal("slow receiver must not block Update")
+	}
+
+	var got []color.RGBA
+	for len(got) < 3 {
+		select {
+		case frame := <-fast:
+			got = append(got, offscreen.Snapshot(frame).RGBAAt(0, 0))
+		case <-time.After(time.Second):
+			t.Fatalf("fast receiver got only %d frames", len(got))
+		}
+	}
+	if got[1] != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("receiver must get a copy of each frame, got %v", got)
+	}
+
+	// Повільний отримувач досі обробляє перший кадр, а з решти отримає лише останній.
+	first, last := <-slow, <-slow
+	if first == last || offscreen.Snapshot(last).RGBAAt(0, 0) != (color.RGBA{0, 0, 0, 255}) {
+		t.Error("slow receiver must skip to the latest frame")
+	}
+
+	m.RemoveReceiver(fast)
+	m.Update(tx)
+	select {
+	case <-fast:
+		t.Error("removed receiver must not get frames")
+	case <-time.After(20 * time.Millisecond):
+	}
+	m.Close()
+}
+
+func TestMultiReceiver_UncopyableTexture(t *testing.T) {
+	var m MultiReceiver
+	m.Update(new(mockTexture))
+	if m.warned {
+		t.Error("frame without receivers must not be copied")
+	}
+
+	r := make(chanReceiver, 1)
+	m.AddReceiver(r)
+	defer m.Close()
+
+	m.Update(new(mockTexture))
+	select {
+	case <-r:
+		t.Error("texture that cannot be copied must not be delivered")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestMultiReceiver_FromRecorder(t *testing.T) {
+	var m MultiReceiver
+	r := make(chanReceiver, 1)
+	m.AddReceiver(r)
+	defer m.Close()
+	rec := FrameRecorder{Next: &m}
+
+	tx, _ := offscreen.NewScreen().NewTexture(image.Pt(4, 4))
+	tx.Fill(tx.Bounds(), color.White, draw.Src)
+	rec.Update(tx)
+	tx.Fill(tx.Bounds(), color.Black, draw.Src)
+
+	select {
+	case frame := <-r:
+		if got := offscreen.Snapshot(frame).RGBAAt(0, 0); got != (color.RGBA{255, 255, 255, 255}) {
+			t.Errorf("receiver must get the recorded copy of the frame, got %v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("receiver got no frame from the recorder")
+	}
+}
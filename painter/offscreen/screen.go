@@ -0,0 +1,216 @@
+// Package offscreen реалізує screen.Screen у пам'яті поверх image.RGBA, без графічного драйвера.
+// Його можна використовувати для запуску painter.Loop на машинах без дисплея та для експорту зображень.
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"golang.org/x/exp/shiny/screen"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// Розмір вікна, якщо у NewWindowOptions його не задано.
+var defaultWindowSize = image.Pt(800, 800)
+
+var (
+	_ screen.Screen  = (*Screen)(nil)
+	_ screen.Buffer  = (*Buffer)(nil)
+	_ screen.Texture = (*Texture)(nil)
+	_ screen.Window  = (*Window)(nil)
+)
+
+// Screen створює буфери, текстури та вікна, що малюють у пам'ять.
+type Screen struct{}
+
+// NewScreen повертає новий екран у пам'яті.
+func NewScreen() *Screen { return &Screen{} }
+
+func (s *Screen) NewBuffer(size image.Point) (screen.Buffer, error) {
+	return &Buffer{rgba: image.NewRGBA(image.Rectangle{Max: size})}, nil
+}
+
+func (s *Screen) NewTexture(size image.Point) (screen.Texture, error) {
+	return &Texture{rgba: image.NewRGBA(image.Rectangle{Max: size})}, nil
+}
+
+func (s *Screen) NewWindow(opts *screen.NewWindowOptions) (screen.Window, error) {
+	size := defaultWindowSize
+	if opts != nil {
+		if opts.Width > 0 {
+			size.X = opts.Width
+		}
+		if opts.Height > 0 {
+			size.Y = opts.Height
+		}
+	}
+	w := &Window{
+		back:  image.NewRGBA(image.Rectangle{Max: size}),
+		front: image.NewRGBA(image.Rectangle{Max: size}),
+	}
+	w.cond.L = &w.mu
+	return w, nil
+}
+
+// Buffer — це screen.Buffer, що зберігає пікселі у image.RGBA.
+type Buffer struct {
+	rgba *image.RGBA
+}
+
+func (b *Buffer) Release()                {}
+func (b *Buffer) Size() image.Point       { return b.rgba.Rect.Size() }
+func (b *Buffer) Bounds() image.Rectangle { return b.rgba.Rect }
+func (b *Buffer) RGBA() *image.RGBA       { return b.rgba }
+
+// Texture — це screen.Texture, що растеризує операції у image.RGBA.
+type Texture struct {
+	mu   sync.Mutex
+	rgba *image.RGBA
+}
+
+// NewTextureFrom повертає текстуру, що малює безпосередньо в img, без копіювання.
+func NewTextureFrom(img *image.RGBA) *Texture {
+	return &Texture{rgba: img}
+}
+
+func (t *Texture) Release()                {}
+func (t *Texture) Size() image.Point       { return t.rgba.Rect.Size() }
+func (t *Texture) Bounds() image.Rectangle { return t.rgba.Rect }
+
+func (t *Texture) Upload(dp image.Point, src screen.Buffer, sr image.Rectangle) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	upload(t.rgba, dp, src, sr)
+}
+
+func (t *Texture) Fill(dr image.Rectangle, src color.Color, op draw.Op) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	draw.Draw(t.rgba, dr, image.NewUniform(src), image.Point{}, op)
+}
+
+// Snapshot повертає копію поточного вмісту текстури.
+func (t *Texture) Snapshot() *image.RGBA {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return clone(t.rgba)
+}
+
+// Snapshot повертає копію вмісту текстури t, якщо вона була створена цим пакетом, або nil.
+func Snapshot(t screen.Texture) *image.RGBA {
+	if ot, ok := t.(*Texture); ok {
+		return ot.Snapshot()
+	}
+	return nil
+}
+
+// Window — це screen.Window з подвійною буферизацією у пам'яті. Publish копіює задній буфер у передній.
+type Window struct {
+	mu     sync.Mutex
+	cond   sync.Cond
+	events []any
+
+	back, front *image.RGBA
+}
+
+func (w *Window) Release() {}
+
+func (w *Window) Send(event any) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events = append(w.events, event)
+	w.cond.Signal()
+}
+
+func (w *Window) SendFirst(event any) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events = append([]any{event}, w.events...)
+	w.cond.Signal()
+}
+
+func (w *Window) NextEvent() any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for len(w.events) == 0 {
+		w.cond.Wait()
+	}
+	e := w.events[0]
+	w.events[0] = nil
+	w.events = w.events[1:]
+	return e
+}
+
+func (w *Window) Upload(dp image.Point, src screen.Buffer, sr image.Rectangle) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	upload(w.back, dp, src, sr)
+}
+
+func (w *Window) Fill(dr image.Rectangle, src color.Color, op draw.Op) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	draw.Draw(w.back, dr, image.NewUniform(src), image.Point{}, op)
+}
+
+func (w *Window) Draw(src2dst f64.Aff3, src screen.Texture, sr image.Rectangle, op draw.Op, opts *screen.DrawOptions) {
+	ot, ok := src.(*Texture)
+	if !ok {
+		return
+	}
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	xdraw.NearestNeighbor.Transform(w.back, src2dst, ot.rgba, sr, op, nil)
+}
+
+func (w *Window) DrawUniform(src2dst f64.Aff3, src color.Color, sr image.Rectangle, op draw.Op, opts *screen.DrawOptions) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	xdraw.NearestNeighbor.Transform(w.back, src2dst, image.NewUniform(src), sr, op, nil)
+}
+
+func (w *Window) Copy(dp image.Point, src screen.Texture, sr image.Rectangle, op draw.Op, opts *screen.DrawOptions) {
+	w.Draw(f64.Aff3{
+		1, 0, float64(dp.X - sr.Min.X),
+		0, 1, float64(dp.Y - sr.Min.Y),
+	}, src, sr, op, opts)
+}
+
+func (w *Window) Scale(dr image.Rectangle, src screen.Texture, sr image.Rectangle, op draw.Op, opts *screen.DrawOptions) {
+	rx := float64(dr.Dx()) / float64(sr.Dx())
+	ry := float64(dr.Dy()) / float64(sr.Dy())
+	w.Draw(f64.Aff3{
+		rx, 0, float64(dr.Min.X) - rx*float64(sr.Min.X),
+		0, ry, float64(dr.Min.Y) - ry*float64(sr.Min.Y),
+	}, src, sr, op, opts)
+}
+
+func (w *Window) Publish() screen.PublishResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	copy(w.front.Pix, w.back.Pix)
+	return screen.PublishResult{BackBufferPreserved: true}
+}
+
+// Snapshot повертає копію останнього опублікованого кадру вікна.
+func (w *Window) Snapshot() *image.RGBA {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return clone(w.front)
+}
+
+func upload(dst *image.RGBA, dp image.Point, src screen.Buffer, sr image.Rectangle) {
+	dr := sr.Sub(sr.Min).Add(dp)
+	draw.Draw(dst, dr, src.RGBA(), sr.Min, draw.Src)
+}
+
+func clone(img *image.RGBA) *image.RGBA {
+	res := image.NewRGBA(img.Rect)
+	copy(res.Pix, img.Pix)
+	return res
+}
@@ -0,0 +1,84 @@
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+var red = color.RGBA{R: 255, A: 255}
+
+func TestTexture_Fill(t *testing.T) {
+	tx, _ := NewScreen().NewTexture(image.Pt(10, 10))
+
+	tx.Fill(tx.Bounds(), color.White, draw.Src)
+	tx.Fill(image.Rect(2, 2, 4, 4), red, draw.Src)
+
+	img := Snapshot(tx)
+	if img == nil {
+		t.Fatal("snapshot of offscreen texture is nil")
+	}
+	if got := img.RGBAAt(3, 3); got != red {
+		t.Errorf("pixel inside filled rect = %v, want %v", got, red)
+	}
+	if got := img.RGBAAt(5, 5); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("pixel outside filled rect = %v, want white", got)
+	}
+}
+
+func TestTexture_Upload(t *testing.T) {
+	s := NewScreen()
+	buf, _ := s.NewBuffer(image.Pt(4, 4))
+	draw.Draw(buf.RGBA(), buf.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+
+	tx, _ := s.NewTexture(image.Pt(10, 10))
+	tx.Upload(image.Pt(5, 5), buf, image.Rect(0, 0, 2, 2))
+
+	img := Snapshot(tx)
+	if got := img.RGBAAt(6, 6); got != red {
+		t.Errorf("uploaded pixel = %v, want %v", got, red)
+	}
+	if got := img.RGBAAt(7, 7); got != (color.RGBA{}) {
+		t.Errorf("pixel outside uploaded rect = %v, want transparent", got)
+	}
+}
+
+func TestWindow_ScaleAndPublish(t *testing.T) {
+	s := NewScreen()
+	tx, _ := s.NewTexture(image.Pt(2, 2))
+	tx.Fill(image.Rect(0, 0, 1, 2), red, draw.Src)
+
+	sw, _ := s.NewWindow(&screen.NewWindowOptions{Width: 8, Height: 8})
+	w := sw.(*Window)
+	w.Scale(image.Rect(0, 0, 8, 8), tx, tx.Bounds(), draw.Src, nil)
+
+	if got := w.Snapshot().RGBAAt(1, 1); got != (color.RGBA{}) {
+		t.Errorf("front buffer changed before Publish: %v", got)
+	}
+
+	w.Publish()
+	img := w.Snapshot()
+	if got := img.RGBAAt(3, 7); got != red {
+		t.Errorf("scaled left half = %v, want %v", got, red)
+	}
+	if got := img.RGBAAt(4, 0); got != (color.RGBA{}) {
+		t.Errorf("scaled right half = %v, want transparent", got)
+	}
+}
+
+func TestWindow_Events(t *testing.T) {
+	sw, _ := NewScreen().NewWindow(nil)
+
+	sw.Send("second")
+	sw.SendFirst("first")
+
+	if e := sw.NextEvent(); e != "first" {
+		t.Errorf("first event = %v", e)
+	}
+	if e := sw.NextEvent(); e != "second" {
+		t.Errorf("second event = %v", e)
+	}
+}
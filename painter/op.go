@@ -36,20 +36,32 @@ func (f OperationFunc) Do(t screen.Texture) bool {
 	return false
 }
 
-func WhiteBackgroundOp(color color.Color) OperationFunc {
+// Кольори, які використовуються, якщо операція не задає власного.
+var (
+	DefaultRectColor   color.Color = color.Black
+	DefaultFigureColor color.Color = color.RGBA{R: 255, G: 230, B: 69, A: 255}
+)
+
+// BackgroundOp заливає всю текстуру кольором c.
+func BackgroundOp(c color.Color) OperationFunc {
 	return OperationFunc(func(t screen.Texture) {
-		t.Fill(t.Bounds(), color, screen.Src)
+		t.Fill(t.Bounds(), c, screen.Src)
 	})
 }
 
+// WhiteBackgroundOp залишено для сумісності, це те саме, що BackgroundOp.
+func WhiteBackgroundOp(color color.Color) OperationFunc {
+	return BackgroundOp(color)
+}
+
+// GreenBackgroundOp залишено для сумісності, це те саме, що BackgroundOp.
 func GreenBackgroundOp(color color.Color) OperationFunc {
-	return OperationFunc(func(t screen.Texture) {
-		t.Fill(t.Bounds(), color, screen.Src)
-	})
+	return BackgroundOp(color)
 }
 
 type BgRect struct {
 	X1, Y1, X2, Y2 int
+	Color          color.Color // якщо nil, використовується DefaultRectColor
 }
 
 func (op BgRect) Do(t screen.Texture) bool {
@@ -60,12 +72,13 @@ func (op BgRect) Do(t screen.Texture) bool {
 func (op *BgRect) BackgroundRect() OperationFunc {
 	return func(t screen.Texture) {
 		bounds := image.Rect(op.X1, op.Y1, op.X2, op.Y2)
-		t.Fill(bounds, color.Black, screen.Src)
+		t.Fill(bounds, orDefault(op.Color, DefaultRectColor), screen.Src)
 	}
 }
 
 type Figure struct {
-	X, Y int
+	X, Y  int
+	Color color.Color // якщо nil, використовується DefaultFigureColor
 }
 
 func (op Figure) Do(t screen.Texture) bool {
@@ -78,7 +91,7 @@ func (op *Figure) Figure() OperationFunc {
 		size := 100
 		thickness := 20
 
-		shapeColor := color.RGBA{R: 255, G: 230, B: 69, A: 255}
+		shapeColor := orDefault(op.Color, DefaultFigureColor)
 
 		x, y := op.X, op.Y
 
@@ -124,3 +137,18 @@ func (ResetOp) Do(t screen.Texture) bool {
 func Reset() Operation {
 	return ResetOp{}
 }
+
+func orDefault(c, def color.Color) color.Color {
+	if c == nil {
+		return def
+	}
+	return c
+}
+
+// CanvasOp змінює розмір полотна. Loop створює нові текстури, тому їх вміст після операції порожній.
+type CanvasOp struct {
+	Size image.Point
+}
+
+// Do нічого не робить з текстурою: розмір змінює сам Loop.
+func (op CanvasOp) Do(t screen.Texture) bool { return false }
@@ -0,0 +1,143 @@
+package painter
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// fpoint — точка з дробовими координатами для растеризації.
+type fpoint struct{ x, y float64 }
+
+// mask — маска пікселів у межах прямокутника r. Фігури спершу растеризуються у маску,
+// щоб перекриття частин контуру не зафарбовувались двічі, а потім заливаються горизонтальними відрізками.
+type mask struct {
+	r    image.Rectangle
+	bits []bool
+}
+
+func newMask(r image.Rectangle) *mask {
+	return &mask{r: r, bits: make([]bool, r.Dx()*r.Dy())}
+}
+
+// span встановлює значення v для пікселів рядка y, центри яких лежать у [x0, x1).
+func (m *mask) span(y int, x0, x1 float64, v bool) {
+	if y < m.r.Min.Y || y >= m.r.Max.Y {
+		return
+	}
+	from := max(int(math.Ceil(x0-0.5)), m.r.Min.X)
+	to := min(int(math.Ceil(x1-0.5)), m.r.Max.X)
+	row := (y - m.r.Min.Y) * m.r.Dx()
+	for x := from; x < to; x++ {
+		m.bits[row+x-m.r.Min.X] = v
+	}
+}
+
+// polygon растеризує многокутник за правилом парності.
+func (m *mask) polygon(pts []fpoint, v bool) {
+	if len(pts) < 3 {
+		return
+	}
+	var xs []float64
+	for y := m.r.Min.Y; y < m.r.Max.Y; y++ {
+		sy := float64(y) + 0.5
+		xs = xs[:0]
+		for i := range pts {
+			a, b := pts[i], pts[(i+1)%len(pts)]
+			if (a.y <= sy) == (b.y <= sy) {
+				continue
+			}
+			xs = append(xs, a.x+(sy-a.y)*(b.x-a.x)/(b.y-a.y))
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			m.span(y, xs[i], xs[i+1], v)
+		}
+	}
+}
+
+func (m *mask) ellipse(c fpoint, rx, ry float64, v bool) {
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+	for y := m.r.Min.Y; y < m.r.Max.Y; y++ {
+		dy := (float64(y) + 0.5 - c.y) / ry
+		if dy < -1 || dy > 1 {
+			continue
+		}
+		half := rx * math.Sqrt(1-dy*dy)
+		m.span(y, c.x-half, c.x+half, v)
+	}
+}
+
+// segment растеризує відрізок товщиною w як прямокутник уздовж нього.
+func (m *mask) segment(a, b fpoint, w float64) {
+	dx, dy := b.x-a.x, b.y-a.y
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		m.ellipse(a, w/2, w/2, true)
+		return
+	}
+	nx, ny := -dy/l*w/2, dx/l*w/2
+	m.polygon([]fpoint{
+		{a.x + nx, a.y + ny}, {b.x + nx, b.y + ny},
+		{b.x - nx, b.y - ny}, {a.x - nx, a.y - ny},
+	}, true)
+}
+
+// polyline растеризує ламану товщиною w. Вершини заокруглюються, щоб на стиках не було щілин.
+func (m *mask) polyline(pts []fpoint, w float64, closed bool) {
+	n := len(pts)
+	if !closed {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		m.segment(pts[i], pts[(i+1)%len(pts)], w)
+	}
+	if w > 2 {
+		for i, p := range pts {
+			if closed || (i > 0 && i < len(pts)-1) {
+				m.ellipse(p, w/2, w/2, true)
+			}
+		}
+	}
+}
+
+// fill заливає текстуру кольором c у пікселях маски, об'єднуючи сусідні пікселі рядка в один прямокутник.
+func (m *mask) fill(t screen.Texture, c color.Color) {
+	w := m.r.Dx()
+	for y := m.r.Min.Y; y < m.r.Max.Y; y++ {
+		row := m.bits[(y-m.r.Min.Y)*w : (y-m.r.Min.Y+1)*w]
+		for x := 0; x < w; {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < w && row[x] {
+				x++
+			}
+			t.Fill(image.Rect(m.r.Min.X+start, y, m.r.Min.X+x, y+1), c, screen.Over)
+		}
+	}
+}
+
+// boundsOf повертає прямокутник, що містить точки pts з запасом pad, обрізаний межами текстури.
+func boundsOf(t screen.Texture, pts []fpoint, pad float64) image.Rectangle {
+	if len(pts) == 0 {
+		return image.Rectangle{}
+	}
+	minX, minY, maxX, maxY := pts[0].x, pts[0].y, pts[0].x, pts[0].y
+	for _, p := range pts[1:] {
+		minX, maxX = math.Min(minX, p.x), math.Max(maxX, p.x)
+		minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+	}
+	r := image.Rect(
+		int(math.Floor(minX-pad)), int(math.Floor(minY-pad)),
+		int(math.Ceil(maxX+pad))+1, int(math.Ceil(maxY+pad))+1,
+	)
+	return r.Intersect(t.Bounds())
+}
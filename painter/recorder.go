@@ -0,0 +1,82 @@
+package painter
+
+import (
+	"image"
+	"sync"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+	"golang.org/x/exp/shiny/screen"
+)
+
+// FrameRecorder зберігає копію останнього кадру, отриманого з Loop, і передає текстуру далі у Next.
+// Кадр можна прочитати лише з текстур пакета offscreen, текстури графічного драйвера лише передаються далі.
+// MultiReceiver у Next отримує вже зроблену копію кадру, тож кадр не копіюється вдруге.
+type FrameRecorder struct {
+	Next Receiver
+
+	mu   sync.RWMutex
+	last *image.RGBA
+	subs map[chan *image.RGBA]struct{}
+}
+
+func (r *FrameRecorder) Update(t screen.Texture) {
+	img := offscreen.Snapshot(t)
+	if img != nil {
+		r.mu.Lock()
+		r.last = img
+		for ch := range r.subs {
+			offer(ch, img)
+		}
+		r.mu.Unlock()
+	}
+	if m, ok := r.Next.(*MultiReceiver); ok && img != nil {
+		m.updateCopy(img)
+	} else if r.Next != nil {
+		r.Next.Update(t)
+	}
+}
+
+// Frame повертає останній збережений кадр або nil, якщо кадрів ще не було. Зображення не можна змінювати.
+func (r *FrameRecorder) Frame() *image.RGBA {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.last
+}
+
+// Subscribe повертає канал, у який надходять нові кадри. Повільний підписник пропускає кадри
+// і отримує найновіший, тож Update ніколи не чекає на нього. cancel відписує та закриває канал.
+func (r *FrameRecorder) Subscribe() (frames <-chan *image.RGBA, cancel func()) {
+	ch := make(chan *image.RGBA, 1)
+
+	r.mu.Lock()
+	if r.subs == nil {
+		r.subs = make(map[chan *image.RGBA]struct{})
+	}
+	r.subs[ch] = struct{}{}
+	r.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			r.mu.Lock()
+			delete(r.subs, ch)
+			r.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// offer надсилає кадр у канал з буфером на один кадр, замінюючи непрочитаний кадр.
+func offer[T any](ch chan T, frame T) {
+	for {
+		select {
+		case ch <- frame:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
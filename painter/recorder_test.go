@@ -0,0 +1,37 @@
+package painter
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+)
+
+func TestFrameRecorder_Subscribe(t *testing.T) {
+	var rec FrameRecorder
+	frames, cancel := rec.Subscribe()
+
+	tx, _ := offscreen.NewScreen().NewTexture(image.Pt(4, 4))
+	for _, c := range []color.Color{color.Black, color.White} {
+		tx.Fill(tx.Bounds(), c, draw.Src)
+		rec.Update(tx)
+	}
+
+	// Підписник не читав кадри, тож Update не чекав на нього, а в каналі лишився лише найновіший.
+	frame := <-frames
+	if got := frame.RGBAAt(0, 0); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("expected the latest white frame, got %v", got)
+	}
+	if frame != rec.Frame() {
+		t.Error("subscriber must get the recorded frame")
+	}
+
+	cancel()
+	cancel()
+	if _, ok := <-frames; ok {
+		t.Error("channel must be closed after cancel")
+	}
+	rec.Update(tx)
+}
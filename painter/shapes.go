@@ -0,0 +1,197 @@
+package painter
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/exp/shiny/screen"
+)
+
+// Shape — фігура сцени, яку можна перемістити. Фігури не змінюються після створення,
+// тому їх можна безпечно передавати у Loop, продовжуючи змінювати сцену.
+type Shape interface {
+	Operation
+	// Moved повертає копію фігури, зміщену на dx, dy пікселів.
+	Moved(dx, dy int) Shape
+	// Anchor повертає точку прив'язки фігури, яку анімація переносить у задане місце.
+	Anchor() image.Point
+}
+
+// DefaultStrokeColor — колір контуру, якщо фігура не задає ні заливки, ні контуру.
+var DefaultStrokeColor color.Color = color.Black
+
+// Style задає заливку та контур фігури. Частина з кольором nil не малюється.
+type Style struct {
+	Fill   color.Color
+	Stroke color.Color
+	Width  int // товщина контуру в пікселях, щонайменше 1
+}
+
+func (s Style) width() float64 {
+	return float64(max(s.Width, 1))
+}
+
+// outline повертає колір контуру, підставляючи DefaultStrokeColor, якщо інакше фігура була б невидимою.
+func (s Style) outline() color.Color {
+	if s.Stroke == nil && s.Fill == nil {
+		return DefaultStrokeColor
+	}
+	return s.Stroke
+}
+
+// Circle — коло з центром X, Y та радіусом R.
+type Circle struct {
+	X, Y, R int
+	Style
+}
+
+func (op *Circle) Do(t screen.Texture) bool {
+	e := Ellipse{X: op.X, Y: op.Y, RX: op.R, RY: op.R, Style: op.Style}
+	return e.Do(t)
+}
+
+func (op *Circle) Moved(dx, dy int) Shape {
+	c := *op
+	c.X, c.Y = c.X+dx, c.Y+dy
+	return &c
+}
+
+func (op *Circle) Anchor() image.Point { return image.Pt(op.X, op.Y) }
+
+// Ellipse — еліпс з центром X, Y та півосями RX, RY.
+type Ellipse struct {
+	X, Y, RX, RY int
+	Style
+}
+
+func (op *Ellipse) Do(t screen.Texture) bool {
+	c := fpoint{float64(op.X), float64(op.Y)}
+	rx, ry := float64(op.RX), float64(op.RY)
+	w := op.width()
+	r := boundsOf(t, []fpoint{{c.x - rx, c.y - ry}, {c.x + rx, c.y + ry}}, w)
+
+	if op.Fill != nil {
+		m := newMask(r)
+		m.ellipse(c, rx, ry, true)
+		m.fill(t, op.Fill)
+	}
+	if stroke := op.outline(); stroke != nil {
+		m := newMask(r)
+		m.ellipse(c, rx+w/2, ry+w/2, true)
+		m.ellipse(c, rx-w/2, ry-w/2, false)
+		m.fill(t, stroke)
+	}
+	return false
+}
+
+func (op *Ellipse) Moved(dx, dy int) Shape {
+	e := *op
+	e.X, e.Y = e.X+dx, e.Y+dy
+	return &e
+}
+
+func (op *Ellipse) Anchor() image.Point { return image.Pt(op.X, op.Y) }
+
+// Line — відрізок від X1, Y1 до X2, Y2. Використовується лише колір контуру.
+type Line struct {
+	X1, Y1, X2, Y2 int
+	Style
+}
+
+func (op *Line) Do(t screen.Texture) bool {
+	pl := Polyline{Points: []image.Point{{op.X1, op.Y1}, {op.X2, op.Y2}}, Style: op.Style}
+	return pl.Do(t)
+}
+
+func (op *Line) Moved(dx, dy int) Shape {
+	l := *op
+	l.X1, l.Y1, l.X2, l.Y2 = l.X1+dx, l.Y1+dy, l.X2+dx, l.Y2+dy
+	return &l
+}
+
+func (op *Line) Anchor() image.Point { return image.Pt(op.X1, op.Y1) }
+
+// Polyline — ламана через точки Points. Використовується лише колір контуру.
+type Polyline struct {
+	Points []image.Point
+	Style
+}
+
+func (op *Polyline) Do(t screen.Texture) bool {
+	stroke := op.Stroke
+	if stroke == nil {
+		stroke = DefaultStrokeColor
+	}
+	pts := fpoints(op.Points)
+	m := newMask(boundsOf(t, pts, op.width()))
+	m.polyline(pts, op.width(), false)
+	m.fill(t, stroke)
+	return false
+}
+
+func (op *Polyline) Moved(dx, dy int) Shape {
+	return &Polyline{Points: movePoints(op.Points, dx, dy), Style: op.Style}
+}
+
+func (op *Polyline) Anchor() image.Point { return firstPoint(op.Points) }
+
+// Polygon — замкнений многокутник з вершинами Points.
+type Polygon struct {
+	Points []image.Point
+	Style
+}
+
+func (op *Polygon) Do(t screen.Texture) bool {
+	pts := fpoints(op.Points)
+	r := boundsOf(t, pts, op.width())
+
+	if op.Fill != nil {
+		m := newMask(r)
+		m.polygon(pts, true)
+		m.fill(t, op.Fill)
+	}
+	if stroke := op.outline(); stroke != nil {
+		m := newMask(r)
+		m.polyline(pts, op.width(), true)
+		m.fill(t, stroke)
+	}
+	return false
+}
+
+func (op *Polygon) Moved(dx, dy int) Shape {
+	return &Polygon{Points: movePoints(op.Points, dx, dy), Style: op.Style}
+}
+
+func (op *Polygon) Anchor() image.Point { return firstPoint(op.Points) }
+
+func (op *Figure) Moved(dx, dy int) Shape {
+	f := *op
+	f.X, f.Y = f.X+dx, f.Y+dy
+	return &f
+}
+
+func (op *Figure) Anchor() image.Point { return image.Pt(op.X, op.Y) }
+
+// firstPoint повертає першу точку ламаної або нуль, якщо точок немає.
+func firstPoint(pts []image.Point) image.Point {
+	if len(pts) == 0 {
+		return image.Point{}
+	}
+	return pts[0]
+}
+
+func fpoints(pts []image.Point) []fpoint {
+	res := make([]fpoint, len(pts))
+	for i, p := range pts {
+		res[i] = fpoint{float64(p.X), float64(p.Y)}
+	}
+	return res
+}
+
+func movePoints(pts []image.Point, dx, dy int) []image.Point {
+	res := make([]image.Point, len(pts))
+	for i, p := range pts {
+		res[i] = p.Add(image.Pt(dx, dy))
+	}
+	return res
+}
@@ -0,0 +1,152 @@
+package painter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
+)
+
+var (
+	red   = color.RGBA{R: 255, A: 255}
+	blue  = color.RGBA{B: 255, A: 255}
+	empty = color.RGBA{}
+)
+
+func render(t *testing.T, op Operation) *image.RGBA {
+	t.Helper()
+	tx, _ := offscreen.NewScreen().NewTexture(image.Pt(100, 100))
+	op.Do(tx)
+	return offscreen.Snapshot(tx)
+}
+
+func checkPixels(t *testing.T, img *image.RGBA, want map[image.Point]color.RGBA) {
+	t.Helper()
+	for p, c := range want {
+		if got := img.RGBAAt(p.X, p.Y); got != c {
+			t.Errorf("pixel %v = %v, want %v", p, got, c)
+		}
+	}
+}
+
+func TestCircle_Do(t *testing.T) {
+	img := render(t, &Circle{X: 50, Y: 50, R: 20, Style: Style{Fill: red, Stroke: blue, Width: 4}})
+
+	checkPixels(t, img, map[image.Point]color.RGBA{
+		{50, 50}: red,
+		{50, 35}: red,
+		{50, 29}: blue,
+		{71, 50}: blue,
+		{50, 75}: empty,
+		{40, 40}: red,
+		{30, 30}: empty,
+	})
+}
+
+func TestEllipse_DefaultStroke(t *testing.T) {
+	img := render(t, &Ellipse{X: 50, Y: 50, RX: 40, RY: 10})
+
+	checkPixels(t, img, map[image.Point]color.RGBA{
+		{50, 50}: empty,
+		{50, 40}: {A: 255},
+		{10, 50}: {A: 255},
+		{50, 30}: empty,
+	})
+}
+
+func TestLine_Do(t *testing.T) {
+	img := render(t, &Line{X1: 10, Y1: 10, X2: 90, Y2: 90, Style: Style{Stroke: red, Width: 3}})
+
+	for i := 10; i < 90; i++ {
+		if got := img.RGBAAt(i, i); got != red {
+			t.Fatalf("pixel on the line (%d,%d) = %v", i, i, got)
+		}
+	}
+	checkPixels(t, img, map[image.Point]color.RGBA{
+		{20, 30}: empty,
+		{95, 95}: empty,
+	})
+}
+
+func TestPolygon_Do(t *testing.T) {
+	img := render(t, &Polygon{
+		Points: []image.Point{{10, 90}, {50, 10}, {90, 90}},
+		Style:  Style{Fill: red},
+	})
+
+	checkPixels(t, img, map[image.Point]color.RGBA{
+		{50, 60}: red,
+		{15, 85}: red,
+		{10, 10}: empty,
+		{85, 20}: empty,
+	})
+}
+
+func TestPolyline_Do(t *testing.T) {
+	img := render(t, &Polyline{
+		Points: []image.Point{{10, 10}, {90, 10}, {90, 90}},
+		Style:  Style{Stroke: blue, Width: 5},
+	})
+
+	checkPixels(t, img, map[image.Point]color.RGBA{
+		{50, 10}: blue,
+		{90, 50}: blue,
+		{90, 10}: blue,
+		{50, 50}: empty,
+		{10, 90}: empty,
+	})
+}
+
+func TestShape_Moved(t *testing.T) {
+	orig := &Polygon{Points: []image.Point{{0, 0}, {10, 0}, {0, 10}}}
+	moved := orig.Moved(5, 5).(*Polygon)
+
+	if moved.Points[0] != image.Pt(5, 5) {
+		t.Errorf("moved polygon starts at %v", moved.Points[0])
+	}
+	if orig.Points[0] != image.Pt(0, 0) {
+		t.Error("Moved must not change the original shape")
+	}
+}
+
+func TestText_Do(t *testing.T) {
+	img := render(t, &Text{X: 50, Y: 60, Text: "MMMM", Size: 24, Color: red, Align: AlignCenter})
+
+	var left, right = 100, 0
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if img.RGBAAt(x, y).A != 0 {
+				left, right = min(left, x), max(right, x)
+				if y > 60 {
+					t.Fatalf("text must stay above the baseline, found pixel at (%d,%d)", x, y)
+				}
+			}
+		}
+	}
+	if left > right {
+		t.Fatal("text was not drawn")
+	}
+	if center := (left + right) / 2; center < 47 || center > 53 {
+		t.Errorf("centered text spans %d..%d", left, right)
+	}
+}
+
+func TestTextFace_Cache(t *testing.T) {
+	faceMu.Lock()
+	defer faceMu.Unlock()
+
+	first, _ := textFace(FontGo, 12.2)
+	if same, _ := textFace(FontGo, 11.8); same != first {
+		t.Error("sizes rounding to the same pixel size must share a face")
+	}
+	for size := 100; size < 100+maxFaces; size++ {
+		_, _ = textFace(FontGo, float64(size))
+	}
+	if len(faces) != maxFaces {
+		t.Fatalf("expected at most %d cached faces, got %d", maxFaces, len(faces))
+	}
+	if again, _ := textFace(FontGo, 12); again == first {
+		t.Error("least recently used face must be evicted")
+	}
+}
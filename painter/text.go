@@ -0,0 +1,170 @@
+package painter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"sync"
+
+	"golang.org/x/exp/shiny/screen"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// DefaultTextSize — кегль напису в пікселях, якщо Text.Size не задано.
+const DefaultTextSize = 16
+
+// DefaultTextColor — колір напису, якщо Text.Color не задано.
+var DefaultTextColor color.Color = color.Black
+
+// Align задає вирівнювання напису відносно точки прив'язки.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// Font обирає шрифт напису.
+type Font int
+
+const (
+	// FontGo — вбудований векторний шрифт Go Regular, що масштабується до будь-якого кегля.
+	FontGo Font = iota
+	// FontBasic — растровий шрифт 7×13 з пакета basicfont. Кегль для нього ігнорується.
+	FontBasic
+)
+
+// Text — однорядковий напис. X, Y — точка прив'язки на базовій лінії тексту.
+type Text struct {
+	X, Y  int
+	Text  string
+	Size  float64
+	Color color.Color
+	Align Align
+	Font  Font
+}
+
+func (op *Text) Do(t screen.Texture) bool {
+	faceMu.Lock()
+	defer faceMu.Unlock()
+
+	face, err := textFace(op.Font, op.Size)
+	if err != nil {
+		return false
+	}
+
+	dot := fixed.P(op.X, op.Y)
+	switch op.Align {
+	case AlignCenter:
+		dot.X -= font.MeasureString(face, op.Text) / 2
+	case AlignRight:
+		dot.X -= font.MeasureString(face, op.Text)
+	}
+
+	bounds, _ := font.BoundString(face, op.Text)
+	bounds = bounds.Add(dot)
+	r := image.Rect(
+		bounds.Min.X.Floor(), bounds.Min.Y.Floor(),
+		bounds.Max.X.Ceil(), bounds.Max.Y.Ceil(),
+	).Intersect(t.Bounds())
+	if r.Empty() {
+		return false
+	}
+
+	// Гліфи растеризуються у маску прозорості, яка потім переноситься на текстуру відрізками однакової прозорості.
+	alpha := image.NewAlpha(r)
+	d := font.Drawer{Dst: alpha, Src: image.Opaque, Face: face, Dot: dot}
+	d.DrawString(op.Text)
+
+	fillAlpha(t, alpha, orDefault(op.Color, DefaultTextColor))
+	return false
+}
+
+func (op *Text) Moved(dx, dy int) Shape {
+	txt := *op
+	txt.X, txt.Y = txt.X+dx, txt.Y+dy
+	return &txt
+}
+
+func (op *Text) Anchor() image.Point { return image.Pt(op.X, op.Y) }
+
+// Найбільша кількість шрифтів різного кегля, які зберігаються одночасно.
+const maxFaces = 16
+
+var (
+	faceMu sync.Mutex   // шрифти opentype не можна використовувати з кількох горутин одночасно
+	faces  []cachedFace // від давно до нещодавно використаних
+	goFont *opentype.Font
+)
+
+type cachedFace struct {
+	size int
+	face font.Face
+}
+
+// textFace повертає шрифт для кегля size, округленого до цілого пікселя. Шрифти зберігаються для повторного
+// використання, а коли їх стає більше за maxFaces, найдавніше використаний закривається.
+func textFace(f Font, size float64) (font.Face, error) {
+	if f == FontBasic {
+		return basicfont.Face7x13, nil
+	}
+	px := DefaultTextSize
+	if size > 0 {
+		px = max(1, int(math.Round(min(size, math.MaxInt16))))
+	}
+	for i, c := range faces {
+		if c.size == px {
+			faces = append(slices.Delete(faces, i, i+1), c)
+			return c.face, nil
+		}
+	}
+
+	if goFont == nil {
+		parsed, err := opentype.Parse(goregular.TTF)
+		if err != nil {
+			return nil, fmt.Errorf("parse Go Regular font: %w", err)
+		}
+		goFont = parsed
+	}
+	face, err := opentype.NewFace(goFont, &opentype.FaceOptions{Size: float64(px), DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	if len(faces) == maxFaces {
+		_ = faces[0].face.Close()
+		faces = slices.Delete(faces, 0, 1)
+	}
+	faces = append(faces, cachedFace{size: px, face: face})
+	return face, nil
+}
+
+// fillAlpha заливає текстуру кольором c з прозорістю з маски, об'єднуючи сусідні пікселі з однаковою прозорістю.
+func fillAlpha(t screen.Texture, m *image.Alpha, c color.Color) {
+	r, g, b, a := c.RGBA()
+	r0 := m.Rect
+	for y := r0.Min.Y; y < r0.Max.Y; y++ {
+		for x := r0.Min.X; x < r0.Max.X; {
+			v := m.AlphaAt(x, y).A
+			start := x
+			for x < r0.Max.X && m.AlphaAt(x, y).A == v {
+				x++
+			}
+			if v == 0 {
+				continue
+			}
+			k := uint32(v) * 0x101
+			scaled := color.RGBA64{
+				R: uint16(r * k / math.MaxUint16), G: uint16(g * k / math.MaxUint16),
+				B: uint16(b * k / math.MaxUint16), A: uint16(a * k / math.MaxUint16),
+			}
+			t.Fill(image.Rect(start, y, x, y+1), scaled, screen.Over)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// ScaleMode визначає, як текстура вписується у вікно.
+type ScaleMode int
+
+const (
+	// ScaleStretch розтягує текстуру на все вікно, не зберігаючи пропорцій.
+	ScaleStretch ScaleMode = iota
+	// ScaleFit вписує текстуру у вікно зі збереженням пропорцій, вільні смуги заливаються кольором LetterboxColor.
+	ScaleFit
+	// ScaleFill заповнює все вікно зі збереженням пропорцій, обрізаючи краї текстури.
+	ScaleFill
+	// ScaleInteger збільшує текстуру у ціле число разів, щоб пікселі лишалися чіткими.
+	ScaleInteger
+)
+
+var scaleModeNames = map[ScaleMode]string{
+	ScaleStretch: "stretch",
+	ScaleFit:     "fit",
+	ScaleFill:    "fill",
+	ScaleInteger: "integer",
+}
+
+func (m ScaleMode) String() string {
+	if name, ok := scaleModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("ScaleMode(%d)", int(m))
+}
+
+// ParseScaleMode повертає режим за його назвою: stretch, fit, fill або integer.
+func ParseScaleMode(name string) (ScaleMode, error) {
+	for m, n := range scaleModeNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown scale mode: %s", name)
+}
+
+// scaleRects обчислює прямокутник у вікні dst, куди малюється текстура, та частину текстури src, яка буде видимою.
+func scaleRects(mode ScaleMode, dst, src image.Rectangle) (dr, sr image.Rectangle) {
+	if dst.Empty() || src.Empty() || mode == ScaleStretch {
+		return dst, src
+	}
+
+	rx := float64(dst.Dx()) / float64(src.Dx())
+	ry := float64(dst.Dy()) / float64(src.Dy())
+
+	switch mode {
+	case ScaleFill:
+		s := math.Max(rx, ry)
+		w := int(math.Round(float64(dst.Dx()) / s))
+		h := int(math.Round(float64(dst.Dy()) / s))
+		return dst, centered(src, w, h)
+
+	case ScaleInteger:
+		if k := math.Floor(math.Min(rx, ry)); k >= 1 {
+			return centered(dst, src.Dx()*int(k), src.Dy()*int(k)), src
+		}
+		// Вікно менше за текстуру, тож зменшуємо її як у ScaleFit.
+	}
+
+	s := math.Min(rx, ry)
+	w := int(math.Round(float64(src.Dx()) * s))
+	h := int(math.Round(float64(src.Dy()) * s))
+	return centered(dst, w, h), src
+}
+
+// centered повертає прямокутник розміру w×h у центрі r.
+func centered(r image.Rectangle, w, h int) image.Rectangle {
+	min := r.Min.Add(image.Pt((r.Dx()-w)/2, (r.Dy()-h)/2))
+	return image.Rectangle{Min: min, Max: min.Add(image.Pt(w, h))}
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"image"
+	"testing"
+)
+
+func TestScaleRects(t *testing.T) {
+	src := image.Rect(0, 0, 400, 200)
+	win := image.Rect(0, 0, 800, 800)
+
+	tests := []struct {
+		mode   ScaleMode
+		dr, sr image.Rectangle
+	}{
+		{ScaleStretch, win, src},
+		{ScaleFit, image.Rect(0, 200, 800, 600), src},
+		{ScaleFill, win, image.Rect(100, 0, 300, 200)},
+		{ScaleInteger, image.Rect(0, 200, 800, 600), src},
+	}
+	for _, tc := range tests {
+		dr, sr := scaleRects(tc.mode, win, src)
+		if dr != tc.dr || sr != tc.sr {
+			t.Errorf("%v: got dr=%v sr=%v, want dr=%v sr=%v", tc.mode, dr, sr, tc.dr, tc.sr)
+		}
+	}
+
+	dr, _ := scaleRects(ScaleInteger, image.Rect(0, 0, 1000, 500), src)
+	if dr != image.Rect(100, 50, 900, 450) {
+		t.Errorf("integer scaling must use whole multiples, got %v", dr)
+	}
+
+	dr, _ = scaleRects(ScaleInteger, image.Rect(0, 0, 200, 200), src)
+	if dr != image.Rect(0, 50, 200, 150) {
+		t.Errorf("integer scaling must shrink like fit when window is too small, got %v", dr)
+	}
+}
+
+func TestParseScaleMode(t *testing.T) {
+	for _, m := range []ScaleMode{ScaleStretch, ScaleFit, ScaleFill, ScaleInteger} {
+		got, err := ParseScaleMode(m.String())
+		if err != nil || got != m {
+			t.Errorf("ParseScaleMode(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := ParseScaleMode("zoom"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
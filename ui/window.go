@@ -4,7 +4,9 @@ import (
 	"image"
 	"image/color"
 	"log"
+	"sync"
 
+	"github.com/roman-mazur/architecture-lab-3/painter/offscreen"
 	"golang.org/x/exp/shiny/driver"
 	"golang.org/x/exp/shiny/imageutil"
 	"golang.org/x/exp/shiny/screen"
@@ -21,10 +23,21 @@ type Visualizer struct {
 	Debug         bool
 	OnScreenReady func(s screen.Screen)
 
+	// Scale визначає, як текстура вписується у вікно. За замовчуванням вона розтягується на все вікно.
+	Scale ScaleMode
+	// LetterboxColor заливає частини вікна, не зайняті текстурою. Якщо nil, використовується чорний.
+	LetterboxColor color.Color
+
 	w    screen.Window
 	tx   chan screen.Texture
 	done chan struct{}
 
+	closeMu  sync.Mutex
+	closeReq chan struct{} // закривається методом Close
+
+	buf screen.Buffer  // буфер для перенесення кадрів з текстур offscreen
+	dtx screen.Texture // текстура драйвера, у яку переносяться кадри offscreen
+
 	sz  size.Event
 	pos image.Rectangle
 }
@@ -37,8 +50,38 @@ func (pw *Visualizer) Main() {
 	driver.Main(pw.run)
 }
 
+// Update передає текстуру вікну. Після закриття вікна текстури відкидаються, щоб не блокувати painter.Loop.
 func (pw *Visualizer) Update(t screen.Texture) {
-	pw.tx <- t
+	select {
+	case pw.tx <- t:
+	case <-pw.done:
+	}
+}
+
+// Close закриває вікно, після чого Main повертається. Метод можна викликати з будь-якої горутини
+// і повторно, зокрема до Main — тоді вікно закриється одразу після створення.
+func (pw *Visualizer) Close() {
+	pw.closeMu.Lock()
+	defer pw.closeMu.Unlock()
+
+	select {
+	case <-pw.closingLocked():
+	default:
+		close(pw.closeReq)
+	}
+}
+
+func (pw *Visualizer) closing() <-chan struct{} {
+	pw.closeMu.Lock()
+	defer pw.closeMu.Unlock()
+	return pw.closingLocked()
+}
+
+func (pw *Visualizer) closingLocked() chan struct{} {
+	if pw.closeReq == nil {
+		pw.closeReq = make(chan struct{})
+	}
+	return pw.closeReq
 }
 
 func (pw *Visualizer) run(s screen.Screen) {
@@ -78,8 +121,14 @@ func (pw *Visualizer) run(s screen.Screen) {
 
 	var t screen.Texture
 
+	closing := pw.closing()
 	for {
 		select {
+		case <-closing:
+			// Вікно закривається так само, як після події lifecycle.StageDead від системи.
+			w.Send(lifecycle.Event{To: lifecycle.StageDead})
+			closing = nil
+
 		case e, ok := <-events:
 			if !ok {
 				return
@@ -87,11 +136,40 @@ func (pw *Visualizer) run(s screen.Screen) {
 			pw.handleEvent(e, t)
 
 		case t = <-pw.tx:
+			t = pw.upload(s, t)
 			w.Send(paint.Event{})
 		}
 	}
 }
 
+// upload переносить кадр з текстури пакета offscreen у текстуру драйвера, щоб його можна було відобразити у вікні.
+// Текстури драйвера повертаються без змін.
+func (pw *Visualizer) upload(s screen.Screen, t screen.Texture) screen.Texture {
+	img := offscreen.Snapshot(t)
+	if img == nil {
+		return t
+	}
+
+	size := img.Bounds().Size()
+	if pw.buf == nil || pw.buf.Size() != size {
+		if pw.buf != nil {
+			pw.buf.Release()
+			pw.dtx.Release()
+		}
+		var err error
+		if pw.buf, err = s.NewBuffer(size); err != nil {
+			log.Fatal("Failed to create a frame buffer:", err)
+		}
+		if pw.dtx, err = s.NewTexture(size); err != nil {
+			log.Fatal("Failed to create a frame texture:", err)
+		}
+	}
+
+	draw.Draw(pw.buf.RGBA(), pw.buf.Bounds(), img, img.Bounds().Min, draw.Src)
+	pw.dtx.Upload(image.Point{}, pw.buf, pw.buf.Bounds())
+	return pw.dtx
+}
+
 func detectTerminate(e any) bool {
 	switch e := e.(type) {
 	case lifecycle.Event:
@@ -129,14 +207,26 @@ func (pw *Visualizer) handleEvent(e any, t screen.Texture) {
 		if t == nil {
 			pw.drawDefaultUI(nil, nil)
 		} else {
-			pw.w.Scale(pw.sz.Bounds(), t, t.Bounds(), draw.Src, nil)
+			pw.drawTexture(t)
 		}
 		pw.w.Publish()
 	}
 }
 
+func (pw *Visualizer) drawTexture(t screen.Texture) {
+	dr, sr := scaleRects(pw.Scale, pw.sz.Bounds(), t.Bounds())
+	if dr != pw.sz.Bounds() {
+		bg := pw.LetterboxColor
+		if bg == nil {
+			bg = color.Black
+		}
+		pw.w.Fill(pw.sz.Bounds(), bg, draw.Src)
+	}
+	pw.w.Scale(dr, t, sr, draw.Src, nil)
+}
+
 func (pw *Visualizer) drawDefaultUI(x, y *int) {
-	pw.w.Fill(pw.sz.Bounds(), color.RGBA{0, 255, 0, 255}, draw.Src) 
+	pw.w.Fill(pw.sz.Bounds(), color.RGBA{0, 255, 0, 255}, draw.Src)
 
 	if x == nil {
 		defaultX := pw.sz.WidthPx / 2
@@ -155,8 +245,8 @@ func (pw *Visualizer) drawDefaultUI(x, y *int) {
 }
 
 func DrawShape(Fill func(dr image.Rectangle, src color.Color, op draw.Op), x, y int, scale float64) {
-	size := int(200 * scale)      
-	thickness := int(40 * scale)   
+	size := int(200 * scale)
+	thickness := int(40 * scale)
 
 	shapeColor := color.RGBA{R: 255, G: 230, B: 69, A: 255}
 
@@ -177,5 +267,3 @@ func DrawShape(Fill func(dr image.Rectangle, src color.Color, op draw.Op), x, y
 	Fill(vertical, shapeColor, draw.Src)
 	Fill(horizontal, shapeColor, draw.Src)
 }
-
-
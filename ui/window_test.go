@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVisualizer_Close(t *testing.T) {
+	var pv Visualizer
+	pv.Close()
+	pv.Close()
+
+	select {
+	case <-pv.closing():
+	default:
+		t.Error("closing channel must be closed after Close")
+	}
+}
+
+func TestVisualizer_UpdateAfterClose(t *testing.T) {
+	pv := Visualizer{done: make(chan struct{})}
+	close(pv.done)
+
+	updated := make(chan struct{})
+	go func() {
+		pv.Update(nil)
+		close(updated)
+	}()
+	select {
+	case <-updated:
+	case <-time.After(time.Second):
+		t.Fatal("Update must not block after the window is closed")
+	}
+}